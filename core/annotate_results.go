@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// annotateResults calls the given annotate function in a go routine for every
+// successful Result with a string value from the input channel, limited by the
+// given lock. Each Result is sent down the returned output channel once the
+// annotate function returns; failed results are passed along untouched. Once
+// the context is done the remaining results are passed along untouched too,
+// so nothing found is lost as long as the output is drained.
+func annotateResults(ctx context.Context, input <-chan *Result, lock *semaphore.Weighted, annotate func(ctx context.Context, result *Result, name string)) <-chan *Result {
+	output := make(chan *Result)
+
+	go func() {
+		defer close(output)
+
+		wg := sync.WaitGroup{}
+
+		send := func(result *Result) {
+			output <- result
+		}
+
+		for result := range input {
+			str, ok := result.GetSuccess().(string)
+			if !result.IsSuccess() || !ok {
+				send(result)
+				continue
+			}
+
+			if err := lock.Acquire(ctx, 1); err != nil {
+				send(result)
+				continue
+			}
+
+			wg.Add(1)
+			go func(result *Result, name string) {
+				defer wg.Done()
+				defer lock.Release(1)
+				annotate(ctx, result, name)
+				send(result)
+			}(result, str)
+		}
+
+		wg.Wait()
+	}()
+
+	return output
+}
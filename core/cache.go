@@ -0,0 +1,126 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// unsafeFileChars matches anything which shouldn't end up in a cache file name.
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9._-]`)
+
+// cacheEntry is what a source found for a domain, as stored on disk.
+type cacheEntry struct {
+	Source  string          `json:"source"`
+	Domain  string          `json:"domain"`
+	Time    time.Time       `json:"time"`
+	Results []*OutputRecord `json:"results"`
+}
+
+// SourceCache keeps what sources found for each domain on disk, so querying
+// the same domain again within the TTL replays the stored results instead of
+// hitting the source. Only complete runs without any failures are stored.
+type SourceCache struct {
+	Dir     string                   // Directory the entries are stored in.
+	TTL     time.Duration            // How long entries are used for, defaults to 24 hours.
+	TTLs    map[string]time.Duration // TTLs for specific sources by SourceName, matched case insensitively, negative to not cache them.
+	Refresh bool                     // Query the sources even when there's an entry, replacing it.
+	once    sync.Once
+}
+
+func (c *SourceCache) init() {
+	c.once.Do(func() {
+		if c.TTL <= 0 {
+			c.TTL = 24 * time.Hour
+		}
+		ttls := map[string]time.Duration{}
+		for name, ttl := range c.TTLs {
+			ttls[strings.ToLower(name)] = ttl
+		}
+		c.TTLs = ttls
+	})
+}
+
+// ttl returns the TTL of the given source.
+func (c *SourceCache) ttl(name string) time.Duration {
+	if ttl, ok := c.TTLs[strings.ToLower(name)]; ok {
+		return ttl
+	}
+	return c.TTL
+}
+
+// Wrap returns a Source which goes through the cache for the given one.
+func (c *SourceCache) Wrap(source Source) Source {
+	c.init()
+
+	name := SourceName(source)
+	ttl := c.ttl(name)
+	if ttl < 0 {
+		return source
+	}
+
+	dir := strings.ToLower(name)
+	// sources configured differently find different things, so they get their own entries
+	if config, err := json.Marshal(source); err == nil && string(config) != "{}" && string(config) != "null" {
+		sum := sha256.Sum256(config)
+		dir += "-" + hex.EncodeToString(sum[:4])
+	}
+
+	records := &cacheRecords{cache: c, name: name, dir: filepath.Join(c.Dir, dir), ttl: ttl}
+	return &recordedSource{Source: source, records: records, name: name}
+}
+
+// WrapAll returns every given Source wrapped with Wrap.
+func (c *SourceCache) WrapAll(sources []Source) []Source {
+	return wrapAll(sources, c.Wrap)
+}
+
+// cacheRecords are the entries of a source in a SourceCache.
+type cacheRecords struct {
+	cache *SourceCache
+	name  string
+	dir   string // Directory of the source's entries.
+	ttl   time.Duration
+}
+
+// path returns the path of the entry of the given domain.
+func (r *cacheRecords) path(domain string) string {
+	return filepath.Join(r.dir, unsafeFileChars.ReplaceAllString(NormalizeName(domain), "_")+".json")
+}
+
+// load returns the results of the entry of the given domain, if there's one which
+// hasn't expired and the cache isn't being refreshed.
+func (r *cacheRecords) load(domain string) ([]*OutputRecord, bool) {
+	if r.cache.Refresh {
+		return nil, false
+	}
+	data, err := os.ReadFile(r.path(domain))
+	if err != nil {
+		return nil, false
+	}
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(data, entry); err != nil || time.Since(entry.Time) > r.ttl {
+		return nil, false
+	}
+	return entry.Results, true
+}
+
+// store writes the entry of the given domain. Failing to is only a missed
+// chance to skip the source next time, so errors are ignored.
+func (r *cacheRecords) store(domain string, records []*OutputRecord) error {
+	data, err := json.Marshal(&cacheEntry{Source: r.name, Domain: domain, Time: time.Now().UTC(), Results: records})
+	if err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil
+	}
+	writeFileAtomically(r.path(domain), data)
+	return nil
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingSource finds www and related names for example.com, counting how often it's queried.
+type countingSource struct {
+	sync.Mutex
+	Fail    bool
+	queries []string
+}
+
+func (s *countingSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	s.Lock()
+	s.queries = append(s.queries, domain)
+	s.Unlock()
+
+	results := make(chan *Result)
+	go func() {
+		defer close(results)
+		if domain == "example.com" {
+			results <- NewResult("counting", "www.example.com", nil)
+			results <- NewResult("counting", RelatedDomain("example.org"), nil)
+		}
+		if s.Fail {
+			results <- NewResult("counting", nil, errors.New("500 Internal Server Error"))
+		}
+	}()
+	return results
+}
+
+// Queries returns the domains queried since the last call.
+func (s *countingSource) Queries() string {
+	s.Lock()
+	defer s.Unlock()
+	sort.Strings(s.queries)
+	queries := strings.Join(s.queries, ",")
+	s.queries = nil
+	return queries
+}
+
+func collectCached(t *testing.T, source Source, domain string) []*Result {
+	results := []*Result{}
+	for result := range source.ProcessDomain(context.Background(), domain) {
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestSourceCache(t *testing.T) {
+	source := &countingSource{}
+	cache := &SourceCache{Dir: t.TempDir()}
+	cached := cache.Wrap(source)
+
+	if name := SourceName(cached); name != "countingSource" {
+		t.Fatalf("expected the name of the wrapped source, got '%v'", name)
+	}
+
+	first := collectCached(t, cached, "example.com")
+	if queries := source.Queries(); queries != "example.com" {
+		t.Fatalf("expected the source to be queried, got '%v'", queries)
+	}
+
+	second := collectCached(t, cached, "Example.com.")
+	if queries := source.Queries(); queries != "" {
+		t.Fatalf("expected the cached results to be used, got queries for '%v'", queries)
+	}
+	if len(second) != 2 || second[0].Success != "www.example.com" || second[1].Success != RelatedDomain("example.org") || !second[0].Timestamp.Equal(first[0].Timestamp) {
+		t.Fatalf("expected the cached results, got '%v'", second)
+	}
+
+	// refreshing queries the source again
+	refreshed := (&SourceCache{Dir: cache.Dir, Refresh: true}).Wrap(source)
+	collectCached(t, refreshed, "example.com")
+	if queries := source.Queries(); queries != "example.com" {
+		t.Fatalf("expected the source to be queried when refreshing, got '%v'", queries)
+	}
+
+	// expired entries are ignored
+	expiring := (&SourceCache{Dir: cache.Dir, TTLs: map[string]time.Duration{"COUNTINGSOURCE": time.Nanosecond}}).Wrap(source)
+	collectCached(t, expiring, "example.com")
+	if queries := source.Queries(); queries != "example.com" {
+		t.Fatalf("expected the source to be queried once expired, got '%v'", queries)
+	}
+
+	// negative TTLs disable the cache
+	disabled := (&SourceCache{Dir: cache.Dir, TTLs: map[string]time.Duration{"countingsource": -1}}).Wrap(source)
+	collectCached(t, disabled, "example.com")
+	if queries := source.Queries(); queries != "example.com" {
+		t.Fatalf("expected the source to be queried when disabled, got '%v'", queries)
+	}
+}
+
+func TestSourceCache_Failures(t *testing.T) {
+	source := &countingSource{Fail: true}
+	cached := (&SourceCache{Dir: t.TempDir()}).Wrap(source)
+
+	for i := 0; i < 2; i++ {
+		if results := collectCached(t, cached, "example.com"); len(results) != 3 || !results[2].IsFailure() {
+			t.Fatalf("expected 2 names and a failure, got '%v'", results)
+		}
+	}
+	if queries := source.Queries(); queries != "example.com,example.com" {
+		t.Fatalf("expected failed runs not to be cached, got '%v'", queries)
+	}
+}
+
+func TestSourceCache_Recursive(t *testing.T) {
+	source := &countingSource{}
+	options := &EnumerationOptions{
+		Sources:   (&SourceCache{Dir: t.TempDir()}).WrapAll([]Source{source}),
+		Recursive: true,
+	}
+
+	for i := 0; i < 2; i++ {
+		count := 0
+		for range EnumerateSubdomains(context.Background(), "example.com", options) {
+			count++
+		}
+		if count != 2 {
+			t.Fatalf("expected 2 results, got %d", count)
+		}
+	}
+
+	// the second run only used the cache, including for the recursion into www.example.com
+	if queries := source.Queries(); queries != "example.com,www.example.com" {
+		t.Fatalf("expected each domain to be queried once, got '%v'", queries)
+	}
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ctPoisonOID is the critical extension which marks a certificate transparency
+// precertificate, as defined in RFC 6962 section 3.1.
+var ctPoisonOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+
+// Certificate contains metadata about the certificate a Result
+// was found on, for sources that search certificates.
+type Certificate struct {
+	ID             string    `json:"id,omitempty"` // Identifier of the certificate at the source.
+	Issuer         string    `json:"issuer"`
+	NotBefore      time.Time `json:"not_before"`
+	NotAfter       time.Time `json:"not_after"`
+	Serial         string    `json:"serial,omitempty"`
+	Expired        bool      `json:"expired"`
+	Precertificate bool      `json:"precertificate"`
+}
+
+// NewCertificate creates the Certificate metadata for a parsed X.509 certificate.
+func NewCertificate(cert *x509.Certificate) *Certificate {
+	return &Certificate{
+		Issuer:         cert.Issuer.String(),
+		NotBefore:      cert.NotBefore.UTC(),
+		NotAfter:       cert.NotAfter.UTC(),
+		Serial:         fmt.Sprintf("%x", cert.SerialNumber),
+		Expired:        time.Now().After(cert.NotAfter),
+		Precertificate: IsPrecertificate(cert),
+	}
+}
+
+// IsPrecertificate checks if the given certificate carries the
+// certificate transparency poison extension.
+func IsPrecertificate(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(ctPoisonOID) {
+			return true
+		}
+	}
+	return false
+}
+
+// CertificateNames returns the common name and the DNS names of the given
+// certificate, each only once regardless of case, as the common name is
+// usually among the DNS names too.
+func CertificateNames(cert *x509.Certificate) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, name := range append([]string{cert.Subject.CommonName}, cert.DNSNames...) {
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,24 @@
+package core
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+)
+
+func TestCertificateNames(t *testing.T) {
+	var units = []struct {
+		cert *x509.Certificate
+		exp  string
+	}{
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "WWW.example.com"}, DNSNames: []string{"www.example.com", "api.example.com", "API.example.com"}}, "WWW.example.com,api.example.com"},
+		{&x509.Certificate{Subject: pkix.Name{CommonName: "example.com"}, DNSNames: []string{"www.example.com"}}, "example.com,www.example.com"},
+		{&x509.Certificate{DNSNames: []string{"www.example.com"}}, "www.example.com"},
+	}
+	for _, u := range units {
+		if names := strings.Join(CertificateNames(u.cert), ","); names != u.exp {
+			t.Fatalf("expected '%v', got '%v'", u.exp, names)
+		}
+	}
+}
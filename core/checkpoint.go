@@ -0,0 +1,147 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// checkpointEntry is a line of a checkpoint file, written once a source finished a domain.
+type checkpointEntry struct {
+	Domain  string          `json:"domain"`
+	Source  string          `json:"source"`
+	Time    time.Time       `json:"time"`
+	Results []*OutputRecord `json:"results"`
+}
+
+func checkpointKey(domain, source string) string {
+	return NormalizeName(domain) + " " + source
+}
+
+// Checkpoint records which sources finished which domains, along with everything
+// they found, so an interrupted enumeration can be resumed without querying them
+// again. It's kept in a JSONL file with a line for each finished (domain, source)
+// pair, which is appended to as soon as the source is done with the domain.
+type Checkpoint struct {
+	file     *os.File
+	finished map[string][]*OutputRecord
+	mutex    sync.Mutex
+}
+
+// OpenCheckpoint creates the checkpoint file at the given path, or continues the
+// existing one when resuming. Creating a checkpoint fails if the file already
+// exists, with an error matching os.ErrExist, so no progress is thrown away.
+func OpenCheckpoint(path string, resume bool) (*Checkpoint, error) {
+	c := &Checkpoint{finished: map[string][]*OutputRecord{}}
+
+	if !resume {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return nil, err
+		}
+		c.file = file
+		return c, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// a line cut short by a crash is dropped, so new lines don't get appended to it
+	complete := bytes.LastIndexByte(data, '\n') + 1
+	if complete < len(data) {
+		if err := file.Truncate(int64(complete)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+
+	for n, line := range bytes.Split(data[:complete], []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		entry := &checkpointEntry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%v line %d: %v", path, n+1, err)
+		}
+		c.finished[checkpointKey(entry.Domain, entry.Source)] = entry.Results
+	}
+
+	c.file = file
+	return c, nil
+}
+
+// Finished returns the number of (domain, source) pairs which are done.
+func (c *Checkpoint) Finished() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.finished)
+}
+
+// results returns what the source found for the domain, if it finished it.
+func (c *Checkpoint) results(domain, source string) ([]*OutputRecord, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	records, ok := c.finished[checkpointKey(domain, source)]
+	return records, ok
+}
+
+// finish records that the source is done with the domain.
+func (c *Checkpoint) finish(domain, source string, records []*OutputRecord) error {
+	line, err := json.Marshal(&checkpointEntry{Domain: NormalizeName(domain), Source: source, Time: time.Now().UTC(), Results: records})
+	if err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.finished[checkpointKey(domain, source)] = records
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the checkpoint file.
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// Wrap returns a Source which replays what the given one found for the
+// domains it finished, and records the domains it finishes from then on.
+// A domain the source failed on isn't finished, so it's retried when resuming.
+func (c *Checkpoint) Wrap(source Source) Source {
+	name := SourceName(source)
+	return &recordedSource{Source: source, records: &checkpointRecords{checkpoint: c, name: name}, name: name}
+}
+
+// WrapAll returns every given Source wrapped with Wrap.
+func (c *Checkpoint) WrapAll(sources []Source) []Source {
+	return wrapAll(sources, c.Wrap)
+}
+
+// checkpointRecords are the finished domains of a source in a Checkpoint.
+type checkpointRecords struct {
+	checkpoint *Checkpoint
+	name       string
+}
+
+func (r *checkpointRecords) load(domain string) ([]*OutputRecord, bool) {
+	return r.checkpoint.results(domain, r.name)
+}
+
+func (r *checkpointRecords) store(domain string, records []*OutputRecord) error {
+	if err := r.checkpoint.finish(domain, r.name, records); err != nil {
+		return fmt.Errorf("checkpoint: %v", err)
+	}
+	return nil
+}
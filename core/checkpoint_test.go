@@ -0,0 +1,112 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stuckSource never finishes, until its context is done.
+type stuckSource struct{}
+
+func (s *stuckSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+	go func() {
+		defer close(results)
+		<-ctx.Done()
+	}()
+	return results
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.jsonl")
+	counting := &countingSource{}
+
+	checkpoint, err := OpenCheckpoint(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := &EnumerationOptions{Sources: checkpoint.WrapAll([]Source{counting, &stuckSource{}})}
+
+	// the stuck source keeps the enumeration going until it's interrupted
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	count := 0
+	for range EnumerateSubdomains(ctx, "example.com", options) {
+		count++
+	}
+	cancel()
+	checkpoint.Close()
+
+	if count != 2 || checkpoint.Finished() != 1 {
+		t.Fatalf("expected 2 results and 1 finished source, got %d and %d", count, checkpoint.Finished())
+	}
+	if queries := counting.Queries(); queries != "example.com" {
+		t.Fatalf("expected the source to be queried, got '%v'", queries)
+	}
+
+	if _, err := OpenCheckpoint(path, false); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected an error for an existing checkpoint, got '%v'", err)
+	}
+
+	// a crash may leave part of a line behind
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	file.WriteString(`{"domain": "exa`)
+	file.Close()
+
+	checkpoint, err = OpenCheckpoint(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoint.Finished() != 1 {
+		t.Fatalf("expected 1 finished source when resuming, got %d", checkpoint.Finished())
+	}
+
+	options = &EnumerationOptions{Sources: checkpoint.WrapAll([]Source{counting})}
+	results := []*Result{}
+	for result := range EnumerateSubdomains(context.Background(), "example.com", options) {
+		results = append(results, result)
+	}
+	if len(results) != 2 || results[0].Success != "www.example.com" || results[1].Success != RelatedDomain("example.org") || results[0].Type != "counting" {
+		t.Fatalf("expected the recorded results to be replayed, got '%v'", results)
+	}
+	if queries := counting.Queries(); queries != "" {
+		t.Fatalf("expected the finished source not to be queried again, got '%v'", queries)
+	}
+
+	// new progress is appended after the dropped partial line
+	for range EnumerateSubdomains(context.Background(), "example.net", options) {
+	}
+	checkpoint.Close()
+
+	checkpoint, err = OpenCheckpoint(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpoint.Close()
+	if checkpoint.Finished() != 2 {
+		t.Fatalf("expected 2 finished sources, got %d", checkpoint.Finished())
+	}
+}
+
+func TestCheckpoint_Failures(t *testing.T) {
+	checkpoint, err := OpenCheckpoint(filepath.Join(t.TempDir(), "checkpoint.jsonl"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer checkpoint.Close()
+
+	source := &countingSource{Fail: true}
+	wrapped := checkpoint.Wrap(source)
+
+	for i := 0; i < 2; i++ {
+		if results := collectCached(t, wrapped, "example.com"); len(results) != 3 || !results[2].IsFailure() {
+			t.Fatalf("expected 2 names and a failure, got '%v'", results)
+		}
+	}
+	if queries := source.Queries(); queries != "example.com,example.com" || checkpoint.Finished() != 0 {
+		t.Fatalf("expected failed runs not to be finished, got '%v' and %d finished", queries, checkpoint.Finished())
+	}
+}
@@ -0,0 +1,177 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// cnameQueryTimeout limits how long a nameserver is waited on, without a deadline on the context.
+const cnameQueryTimeout = 5 * time.Second
+
+// CNAMEResolver is the default TakeoverResolver. Unlike net.Resolver, whose
+// LookupCNAME follows the whole chain to the canonical name and fails when a
+// dangling one ends in NXDOMAIN, its LookupCNAME queries the CNAME record of
+// the name itself, returning the next hop, so every target can be matched.
+type CNAMEResolver struct {
+	Servers  []string      // Nameservers queried in turn, defaults to those of /etc/resolv.conf or else the public ones of GeneralOptions.
+	Resolver *net.Resolver // Used by LookupHost, defaults to net.DefaultResolver.
+}
+
+// systemNameservers returns the nameservers of /etc/resolv.conf, if any.
+func systemNameservers() []string {
+	file, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	servers := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// servers returns the nameservers to query, with their port.
+func (r *CNAMEResolver) servers() []string {
+	servers := r.Servers
+	if len(servers) == 0 {
+		servers = systemNameservers()
+	}
+	if len(servers) == 0 {
+		servers = defaultDNSResolvers
+	}
+
+	addrs := []string{}
+	for _, server := range servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, "53")
+		}
+		addrs = append(addrs, server)
+	}
+	return addrs
+}
+
+// LookupCNAME returns the target of the CNAME record of the given host, or the
+// host itself when it has none, like net.Resolver does for canonical names.
+func (r *CNAMEResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	name, err := dnsmessage.NewName(strings.TrimSuffix(host, ".") + ".")
+	if err != nil {
+		return "", &net.DNSError{Err: err.Error(), Name: host}
+	}
+
+	var lastErr error
+	for _, server := range r.servers() {
+		cname, err := r.query(ctx, server, name)
+		if err == nil {
+			return cname, nil
+		}
+		lastErr = err
+		// a name which doesn't exist won't on the other nameservers either
+		if isNotFound(err) || ctx.Err() != nil {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+// query asks the given nameserver for the CNAME record of the given name.
+func (r *CNAMEResolver) query(ctx context.Context, server string, name dnsmessage.Name) (string, error) {
+	id := uint16(rand.Intn(1 << 16))
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	builder.EnableCompression()
+	builder.StartQuestions()
+	builder.Question(dnsmessage.Question{Name: name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET})
+	query, err := builder.Finish()
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "udp", server)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > cnameQueryTimeout {
+		deadline = time.Now().Add(cnameQueryTimeout)
+	}
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() {
+		conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if _, err := conn.Write(query); err != nil {
+		return "", err
+	}
+
+	buffer := make([]byte, 4096)
+	for {
+		n, err := conn.Read(buffer)
+		if err != nil {
+			return "", err
+		}
+
+		parser := dnsmessage.Parser{}
+		header, err := parser.Start(buffer[:n])
+		// anything else on the socket isn't the response
+		if err != nil || header.ID != id || !header.Response {
+			continue
+		}
+
+		switch header.RCode {
+		case dnsmessage.RCodeSuccess:
+		case dnsmessage.RCodeNameError:
+			return "", &net.DNSError{Err: "no such host", Name: name.String(), Server: server, IsNotFound: true}
+		default:
+			return "", &net.DNSError{Err: "server misbehaving: " + header.RCode.String(), Name: name.String(), Server: server}
+		}
+
+		if err := parser.SkipAllQuestions(); err != nil {
+			return "", err
+		}
+		for {
+			answer, err := parser.AnswerHeader()
+			if errors.Is(err, dnsmessage.ErrSectionDone) {
+				return name.String(), nil
+			}
+			if err != nil {
+				return "", err
+			}
+			if answer.Type != dnsmessage.TypeCNAME || !strings.EqualFold(answer.Name.String(), name.String()) {
+				if err := parser.SkipAnswer(); err != nil {
+					return "", err
+				}
+				continue
+			}
+			cname, err := parser.CNAMEResource()
+			if err != nil {
+				return "", err
+			}
+			return cname.CNAME.String(), nil
+		}
+	}
+}
+
+// LookupHost looks up the addresses of the given host.
+func (r *CNAMEResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	resolver := r.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return resolver.LookupHost(ctx, host)
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveTestCNAMEs answers the CNAME queries on the returned address from the
+// given records, with NXDOMAIN for the names in neither of them.
+func serveTestCNAMEs(t *testing.T, cnames map[string]string, hosts map[string]bool) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+
+			query := dnsmessage.Message{}
+			if err := query.Unpack(buffer[:n]); err != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			name := strings.TrimSuffix(question.Name.String(), ".")
+
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, RecursionAvailable: true},
+				Questions: query.Questions,
+			}
+			if cname, ok := cnames[name]; ok {
+				response.Answers = append(response.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeCNAME, Class: dnsmessage.ClassINET},
+					Body:   &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(cname + ".")},
+				})
+			} else if !hosts[name] {
+				response.RCode = dnsmessage.RCodeNameError
+			}
+
+			packed, err := response.Pack()
+			if err != nil {
+				continue
+			}
+			conn.WriteTo(packed, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestCNAMEResolver(t *testing.T) {
+	server := serveTestCNAMEs(t, map[string]string{
+		"app.example.com":  "edge.example.net",
+		"edge.example.net": "gone.azurewebsites.net",
+	}, map[string]bool{"www.example.com": true})
+
+	// the whole chain is followed, up to the dangling target
+	checker := &TakeoverChecker{Resolver: &CNAMEResolver{Servers: []string{server}}}
+	checker.init()
+
+	var units = []struct {
+		name  string
+		chain string
+	}{
+		{"app.example.com", "edge.example.net,gone.azurewebsites.net"},
+		{"www.example.com", ""},
+	}
+	for _, u := range units {
+		chain, err := checker.cnameChain(context.Background(), u.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(chain, ",") != u.chain {
+			t.Fatalf("expected '%v' for %v, got '%v'", u.chain, u.name, chain)
+		}
+	}
+
+	if _, err := checker.Resolver.LookupCNAME(context.Background(), "missing.example.com"); !isNotFound(err) {
+		t.Fatalf("expected NXDOMAIN for missing.example.com, got '%v'", err)
+	}
+}
@@ -0,0 +1,193 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// The changes a DiffEntry can have.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+)
+
+// DiffEntry is a name which was added or removed between two sets of results.
+type DiffEntry struct {
+	Name   string `json:"name"`
+	Change string `json:"change"` // Either DiffAdded or DiffRemoved.
+}
+
+// isHostname checks if the given string looks like a host name, rather than
+// a source label, an error message or a probe.
+func isHostname(str string) bool {
+	if !strings.Contains(str, ".") {
+		return false
+	}
+	for _, c := range str {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '-', c == '_', c == '*':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isSourceLabel checks if the given string looks like a source label, which
+// plain output is prefixed with when labeled: lowercase letters, digits and
+// dashes, without the dots of a host name.
+func isSourceLabel(str string) bool {
+	if str == "" {
+		return false
+	}
+	for _, c := range str {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseResultLine returns the normalized subdomain from a line of plain or JSONL
+// output, or an empty string when the line doesn't have one, like for errors or
+// related domains.
+func parseResultLine(line string) (string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", nil
+	}
+
+	if strings.HasPrefix(line, "{") {
+		record := &OutputRecord{}
+		if err := json.Unmarshal([]byte(line), record); err != nil {
+			return "", err
+		}
+		if record.Related || record.Error != "" {
+			return "", nil
+		}
+		return NormalizeName(record.Subdomain), nil
+	}
+
+	// plain output has the name first, or second when labeled with a source,
+	// only followed by its probes or takeover, so errors mentioning hosts are skipped
+	fields := strings.Fields(line)
+	name, rest := fields[0], fields[1:]
+	if isSourceLabel(name) && len(rest) > 0 {
+		name, rest = rest[0], rest[1:]
+	}
+	if !isHostname(name) {
+		return "", nil
+	}
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "[") && rest[0] != "vulnerable" && rest[0] != "possible" {
+		return "", nil
+	}
+
+	return NormalizeName(name), nil
+}
+
+// ReadNames reads the subdomains from plain or JSONL output, normalizing their case
+// and trailing dots. Each name is only returned once, in the order first seen.
+func ReadNames(r io.Reader) ([]string, error) {
+	names := []string{}
+	seen := map[string]bool{}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for n := 1; scanner.Scan(); n++ {
+		name, err := parseResultLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	return names, scanner.Err()
+}
+
+// DiffNames compares two sets of names, returning the names only in new as added and the
+// names only in old as removed, sorted by name. Names are normalized before being compared.
+func DiffNames(old, new []string) []*DiffEntry {
+	oldNames := map[string]bool{}
+	for _, name := range old {
+		oldNames[NormalizeName(name)] = true
+	}
+
+	newNames := map[string]bool{}
+	for _, name := range new {
+		newNames[NormalizeName(name)] = true
+	}
+
+	entries := []*DiffEntry{}
+	for name := range newNames {
+		if !oldNames[name] {
+			entries = append(entries, &DiffEntry{Name: name, Change: DiffAdded})
+		}
+	}
+	for name := range oldNames {
+		if !newNames[name] {
+			entries = append(entries, &DiffEntry{Name: name, Change: DiffRemoved})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+// DiffEntryWriter is implemented by the ResultWriters which can write
+// DiffEntries along with the Results, those of the plain and JSONL formats
+// and those writing to them, like files and output directories.
+type DiffEntryWriter interface {
+	WriteDiffEntry(*DiffEntry) error
+}
+
+// writeDiffEntry writes the given entry with the ResultWriter, if it can.
+func writeDiffEntry(writer ResultWriter, entry *DiffEntry) error {
+	if writer, ok := writer.(DiffEntryWriter); ok {
+		return writer.WriteDiffEntry(entry)
+	}
+	return errors.New("only the plain and jsonl output formats can write a diff")
+}
+
+// WriteDiff writes the given entries in the plain or JSONL output format. Plain
+// output prefixes added names with a + and removed names with a -.
+func WriteDiff(w io.Writer, format string, entries []*DiffEntry) error {
+	switch NormalizeOutputFormat(format) {
+	case OutputPlain, OutputJSONL:
+	default:
+		return fmt.Errorf("unknown diff format %q, expected plain or jsonl", format)
+	}
+	writer, err := NewResultWriter(w, format, false)
+	if err != nil {
+		return err
+	}
+	if err := WriteDiffEntries(writer, entries); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// WriteDiffEntries writes the given entries with a ResultWriter, so they go to
+// the same outputs as the Results, failing unless it's a DiffEntryWriter.
+func WriteDiffEntries(writer ResultWriter, entries []*DiffEntry) error {
+	for _, entry := range entries {
+		if err := writeDiffEntry(writer, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
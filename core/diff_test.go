@@ -0,0 +1,122 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadNames(t *testing.T) {
+	var units = []struct {
+		input string
+		exp   string
+	}{
+		{"www.example.com\nWWW.Example.com.\n\napi.example.com\n", "www.example.com,api.example.com"},
+		{"crtsh www.example.com\ncrtsh related example.org\nvirustotal 403 Forbidden\n", "www.example.com"},
+		{"www.example.com [https://www.example.com/ 200 \"Example\"]\n", "www.example.com"},
+		{"crtsh www.example.com possible heroku takeover: dangling CNAME\n", "www.example.com"},
+		// errors mentioning a host, with or without a label
+		{"lookup www.example.com: no such host\nvirustotal lookup www.example.com on 127.0.0.53:53: timeout\ncrtsh www.example.com timed out\n", ""},
+		{"google-suggestions www.example.com\n", "www.example.com"},
+		{"Error: www.example.com\n", ""},
+		{
+			`{"timestamp":"2020-01-02T03:04:05Z","domain":"example.com","source":"crtsh","subdomain":"Mail.example.com."}` + "\n" +
+				`{"timestamp":"2020-01-02T03:04:05Z","domain":"example.com","source":"crtsh","subdomain":"example.org","related":true}` + "\n" +
+				`{"timestamp":"2020-01-02T03:04:05Z","domain":"example.com","source":"virustotal","error":"403 Forbidden"}` + "\n",
+			"mail.example.com",
+		},
+	}
+	for _, u := range units {
+		names, err := ReadNames(strings.NewReader(u.input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(names, ",") != u.exp {
+			t.Fatalf("expected '%v', got '%v'", u.exp, strings.Join(names, ","))
+		}
+	}
+
+	if _, err := ReadNames(strings.NewReader("{not json\n")); err == nil {
+		t.Fatal("expected an error for invalid JSON")
+	}
+}
+
+func TestDiffNames(t *testing.T) {
+	entries := DiffNames(
+		[]string{"a.example.com", "B.example.com.", "c.example.com"},
+		[]string{"b.example.com", "c.example.com", "d.example.com"},
+	)
+
+	buffer := &bytes.Buffer{}
+	if err := WriteDiff(buffer, OutputPlain, entries); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "- a.example.com\n+ d.example.com\n"; buffer.String() != exp {
+		t.Fatalf("expected '%v', got '%v'", exp, buffer.String())
+	}
+
+	buffer.Reset()
+	if err := WriteDiff(buffer, OutputJSONL, entries); err != nil {
+		t.Fatal(err)
+	}
+	if exp := `{"name":"a.example.com","change":"removed"}` + "\n" + `{"name":"d.example.com","change":"added"}` + "\n"; buffer.String() != exp {
+		t.Fatalf("expected '%v', got '%v'", exp, buffer.String())
+	}
+
+	if err := WriteDiff(buffer, OutputCSV, entries); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
+
+func TestWriteDiffEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	file, err := CreateResultFile(filepath.Join(dir, "results.txt"), OutputPlain, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirWriter, err := NewOutputDirWriter(filepath.Join(dir, "out"), OutputPlain, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := MultiResultWriter(file, dirWriter)
+
+	result := NewResult("crtsh", "www.example.com", nil)
+	result.SetDomain("example.com")
+	if err := writer.WriteResult(result); err != nil {
+		t.Fatal(err)
+	}
+
+	// the removed names go to the same outputs as the results
+	entries := []*DiffEntry{
+		{Name: "api.example.com", Change: DiffRemoved},
+		{Name: "www.example.org", Change: DiffRemoved},
+	}
+	if err := WriteDiffEntries(writer, entries); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, exp := range map[string]string{
+		"results.txt":         "www.example.com\n- api.example.com\n- www.example.org\n",
+		"out/example.com.txt": "www.example.com\n- api.example.com\n",
+		"out/example.org.txt": "- www.example.org\n",
+	} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != exp {
+			t.Fatalf("expected '%v' in %v, got '%v'", exp, path, string(data))
+		}
+	}
+
+	csvWriter, _ := NewResultWriter(&bytes.Buffer{}, OutputCSV, false)
+	if err := WriteDiffEntries(csvWriter, entries); err == nil {
+		t.Fatal("expected an error for the csv format")
+	}
+}
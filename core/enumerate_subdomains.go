@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"sync"
+	"time"
 )
 
 // EnumerateSubdomains takes the given domain and with each Source from EnumerationOptions,
@@ -27,6 +28,11 @@ func EnumerateSubdomains(ctx context.Context, domain string, options *Enumeratio
 		// close up the combined results channel when the go parent func returns
 		defer close(results)
 
+		if options.Metrics != nil {
+			options.Metrics.enumerating(1)
+			defer options.Metrics.enumerating(-1)
+		}
+
 		// a wait group to ensure all child go funcs finish processing
 		wg := sync.WaitGroup{}
 
@@ -42,14 +48,47 @@ func EnumerateSubdomains(ctx context.Context, domain string, options *Enumeratio
 				// Tell the wait group a job has been completed when the go func returns
 				defer wg.Done()
 
+				// the statistics of the source, which its requests are counted in through the context
+				stats := options.collector()
+				counters := stats.source(SourceName(source))
+
+				// the logger of the source, which it gets through the context as well
+				logger := options.Logger
+				if logger == nil {
+					logger = Logger(ctx)
+				}
+				logger = logger.With("source", SourceName(source), "domain", domain)
+				sourceCtx := WithLogger(withSourceStats(ctx, counters), logger)
+
+				found := 0
+				started := counters.start()
+				logger.Debug("source started")
+				defer func() {
+					counters.finish(time.Since(started))
+					logger.Debug("source finished", "duration", time.Since(started), "results", found, "canceled", ctx.Err() != nil)
+				}()
+
 				// get the results channel from the source calling the ProcessDomain method on it
-				sourceResults := source.ProcessDomain(ctx, domain)
+				sourceResults := source.ProcessDomain(sourceCtx, domain)
 
 				// for loop over results in a select to allow for timeout
 				for {
 					select {
 					case result, ok := <-sourceResults:
 						if ok {
+							if options.ExcludeExpired {
+								if cert := result.GetCertificate(); cert != nil && cert.Expired {
+									continue
+								}
+							}
+							// tag the result with the domain being enumerated
+							result.SetDomain(domain)
+							stats.record(counters, result)
+							if result.IsFailure() {
+								logger.Info("source error", "error", result.GetFailure(), "kind", ErrorKind(result.GetFailure()))
+							} else {
+								found++
+							}
 							select {
 							case results <- result:
 								// initial recursion implementation
@@ -60,9 +99,11 @@ func EnumerateSubdomains(ctx context.Context, domain string, options *Enumeratio
 									}
 									wg.Add(1)
 
-									go func(results chan *Result, domain string, options *EnumerationOptions) {
+									go func(results chan *Result, subdomain string, options *EnumerationOptions) {
 										defer wg.Done()
-										for result := range EnumerateSubdomains(ctx, domain, options) {
+										for result := range EnumerateSubdomains(ctx, subdomain, options) {
+											// keep the domain this enumeration started from
+											result.SetDomain(domain)
 											select {
 											case <-ctx.Done():
 												return
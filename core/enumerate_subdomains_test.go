@@ -133,3 +133,42 @@ func ExampleEnumerateSubdomains() {
 	fmt.Println(counter)
 	// Output: 6
 }
+
+type FakeCertificateSource struct{}
+
+func (s *FakeCertificateSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+
+	go func(domain string) {
+		defer close(results)
+		for _, expired := range []bool{true, false, true} {
+			result := NewResult("fake", fmt.Sprintf("%v.%v", expired, domain), nil)
+			result.SetCertificate(&Certificate{Expired: expired})
+			results <- result
+		}
+	}(domain)
+	return results
+}
+
+func TestEnumerateSubdomains_ExcludeExpired(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	options := &EnumerationOptions{
+		Sources:        []Source{&FakeCertificateSource{}},
+		ExcludeExpired: true,
+	}
+
+	counter := 0
+
+	for result := range EnumerateSubdomains(ctx, "google.com", options) {
+		counter++
+		if result.GetCertificate().Expired {
+			t.Fatalf("expected no expired certificates, got '%v'", result.Success)
+		}
+	}
+
+	if counter != 1 {
+		t.Error(counter)
+	}
+}
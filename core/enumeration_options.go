@@ -2,17 +2,24 @@ package core
 
 import (
 	"context"
+	"log/slog"
+	"sync"
 )
 
 // EnumerationOptions provides all the data needed for subdomain
 // enumeration. This includes all the sources which will be
 // queried to find them.
 type EnumerationOptions struct {
-	Sources   []Source
-	Context   context.Context
-	Recursive bool
-	Debug     bool
-	Uniq      bool
+	Sources        []Source
+	Context        context.Context
+	Recursive      bool
+	Debug          bool
+	Uniq           bool
+	ExcludeExpired bool         // Drop results only found on an expired certificate.
+	Metrics        *Metrics     // Also count the statistics of the sources in these, if set.
+	Logger         *slog.Logger // Sources log to this through their context, see Logger.
+	statsOnce      sync.Once
+	stats          *statsCollector
 }
 
 // HasSources checks if the EnumerationOptions have any source defined.
@@ -22,3 +29,21 @@ func (opts *EnumerationOptions) HasSources() bool {
 	}
 	return true
 }
+
+// collector returns the statsCollector of every enumeration done with the options.
+func (opts *EnumerationOptions) collector() *statsCollector {
+	opts.statsOnce.Do(func() {
+		opts.stats = &statsCollector{metrics: opts.Metrics, sources: map[string]*sourceCounters{}, found: map[string]int{}}
+	})
+	return opts.stats
+}
+
+// Stats returns the statistics of each source, summed over every enumeration
+// done with the options so far, including those still running.
+func (opts *EnumerationOptions) Stats() *Stats {
+	names := []string{}
+	for _, source := range opts.Sources {
+		names = append(names, SourceName(source))
+	}
+	return opts.collector().stats(names)
+}
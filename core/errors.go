@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+)
+
+// The kinds of errors told apart by ErrorKind.
+const (
+	ErrorTimeout    = "timeout"     // A deadline was exceeded.
+	ErrorCanceled   = "canceled"    // The context was canceled.
+	ErrorNetwork    = "network"     // A connection failed.
+	ErrorHTTPStatus = "http_status" // A response had an unexpected status, see HTTPStatus.
+	ErrorParse      = "parse"       // A response couldn't be parsed.
+	ErrorOther      = "other"       // Anything else.
+)
+
+// httpStatusRegexp matches the errors sources create from a response's Status, like "404 Not Found".
+var httpStatusRegexp = regexp.MustCompile(`^([1-5][0-9][0-9])( |$)`)
+
+// HTTPStatus returns the status code of an error created from an HTTP response status, or 0.
+func HTTPStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	match := httpStatusRegexp.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 0
+	}
+	code, _ := strconv.Atoi(match[1])
+	return code
+}
+
+// ErrorKind returns the broad category of the error of a failed Result,
+// or an empty string for a nil error.
+func ErrorKind(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorCanceled
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return ErrorTimeout
+		}
+		return ErrorNetwork
+	}
+
+	if HTTPStatus(err) != 0 {
+		return ErrorHTTPStatus
+	}
+
+	var jsonSyntaxErr *json.SyntaxError
+	var jsonTypeErr *json.UnmarshalTypeError
+	var xmlSyntaxErr *xml.SyntaxError
+	if errors.As(err, &jsonSyntaxErr) || errors.As(err, &jsonTypeErr) || errors.As(err, &xmlSyntaxErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorParse
+	}
+
+	return ErrorOther
+}
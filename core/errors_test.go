@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+)
+
+func TestErrorKind(t *testing.T) {
+	var syntaxErr error = json.Unmarshal([]byte("{"), &struct{}{})
+	var typeErr error = json.Unmarshal([]byte(`{"a": 1}`), &struct{ A string }{})
+
+	var units = []struct {
+		err    error
+		kind   string
+		status int
+	}{
+		{nil, "", 0},
+		{context.DeadlineExceeded, ErrorTimeout, 0},
+		{fmt.Errorf("page 2: %w", context.Canceled), ErrorCanceled, 0},
+		{&url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, ErrorNetwork, 0},
+		{errors.New("404 Not Found"), ErrorHTTPStatus, 404},
+		{errors.New("503"), ErrorHTTPStatus, 503},
+		{syntaxErr, ErrorParse, 0},
+		{typeErr, ErrorParse, 0},
+		{errors.New("rate limited on page 2"), ErrorOther, 0},
+		{errors.New("1000 names"), ErrorOther, 0},
+	}
+	for _, u := range units {
+		if kind := ErrorKind(u.err); kind != u.kind {
+			t.Fatalf("expected kind '%v' for '%v', got '%v'", u.kind, u.err, kind)
+		}
+		if status := HTTPStatus(u.err); status != u.status {
+			t.Fatalf("expected status %d for '%v', got %d", u.status, u.err, status)
+		}
+	}
+}
@@ -15,9 +15,9 @@ type GeneralOptions struct {
 	Recursive      bool          // Perform recursive subdomain discovery or not.
 	PassiveOnly    bool          // Perform only passive subdomain discovery or not.
 	IgnoreErrors   bool          // Ignore errors or not.
-	OutputType     string        // Type of output wanted (json, plaintext, ect).
+	OutputType     string        // Type of output wanted, one of OutputFormats (plaintext is the same as plain).
 	Sources        []Source      // List of source types to use.
-	OutputDir      string        // Directory to use for any output.
+	OutputDir      string        // Directory to use for any output, see OutputDirWriter.
 	Resolvers      []string      // List of DNS resolvers to use.
 }
 
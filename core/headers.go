@@ -0,0 +1,136 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// BrowserUserAgents are realistic User-Agents of current browsers, which
+// HeaderPolicy rotates through unless given its own.
+var BrowserUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:144.0) Gecko/20100101 Firefox/144.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:144.0) Gecko/20100101 Firefox/144.0",
+	"Mozilla/5.0 (X11; Linux x86_64; rv:144.0) Gecko/20100101 Firefox/144.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/26.0 Safari/605.1.15",
+}
+
+// DefaultUserAgent is the User-Agent of requests when none was configured.
+var DefaultUserAgent = BrowserUserAgents[0]
+
+// HeaderPolicy decides the headers added to the requests of sources: a
+// User-Agent, either the same one or rotating through a pool on every
+// request, and any custom headers, like the identification headers some
+// bug bounty programs require. Both can be overridden for each source,
+// by its name. Headers set by the sources themselves, like those carrying
+// API keys, are kept.
+type HeaderPolicy struct {
+	UserAgent        string                    `json:"user_agent,omitempty"`         // Defaults to DefaultUserAgent.
+	RotateUserAgents bool                      `json:"rotate_user_agents,omitempty"` // Use the next of UserAgents on every request, instead of UserAgent.
+	UserAgents       []string                  `json:"user_agents,omitempty"`        // Pool of User-Agents to rotate through, defaults to BrowserUserAgents.
+	Headers          map[string]string         `json:"headers,omitempty"`            // Added to every request.
+	Sources          map[string]*SourceHeaders `json:"sources,omitempty"`            // Overrides by source name, like "Bing".
+	next             uint64
+}
+
+// SourceHeaders overrides the HeaderPolicy for a single source.
+type SourceHeaders struct {
+	UserAgent string            `json:"user_agent,omitempty"` // Used instead of the User-Agent of the policy, if set.
+	Headers   map[string]string `json:"headers,omitempty"`    // Added to the headers of the policy, replacing those of the same name.
+}
+
+// LoadHeaderPolicy reads a HeaderPolicy from JSON.
+func LoadHeaderPolicy(r io.Reader) (*HeaderPolicy, error) {
+	policy := &HeaderPolicy{}
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(policy); err != nil {
+		return nil, fmt.Errorf("invalid header policy: %v", err)
+	}
+	return policy, nil
+}
+
+// userAgent returns the User-Agent of the next request of the given source.
+func (p *HeaderPolicy) userAgent(source string) string {
+	if override, ok := p.Sources[source]; ok && override.UserAgent != "" {
+		return override.UserAgent
+	}
+	if p.RotateUserAgents {
+		pool := p.UserAgents
+		if len(pool) == 0 {
+			pool = BrowserUserAgents
+		}
+		next := atomic.AddUint64(&p.next, 1) - 1
+		return pool[next%uint64(len(pool))]
+	}
+	if p.UserAgent != "" {
+		return p.UserAgent
+	}
+	return DefaultUserAgent
+}
+
+// Apply adds the headers of the policy to the request, for the source
+// EnumerateSubdomains gave its context, if any, keeping those already set.
+func (p *HeaderPolicy) Apply(req *http.Request) {
+	source, _ := sourceName(req.Context())
+
+	headers := map[string]string{}
+	for name, value := range p.Headers {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+	if override, ok := p.Sources[source]; ok {
+		for name, value := range override.Headers {
+			headers[http.CanonicalHeaderKey(name)] = value
+		}
+	}
+	for name, value := range headers {
+		if _, ok := req.Header[name]; !ok {
+			req.Header.Set(name, value)
+		}
+	}
+
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", p.userAgent(source))
+	}
+}
+
+// headerPolicy is the policy set with SetHeaderPolicy.
+var headerPolicy atomic.Pointer[HeaderPolicy]
+
+// defaultHeaderPolicy only sets the DefaultUserAgent.
+var defaultHeaderPolicy = &HeaderPolicy{}
+
+// SetHeaderPolicy sets the headers of the requests of HTTPClient, and of any
+// other clients using ApplyHeaders, or only the DefaultUserAgent when nil.
+func SetHeaderPolicy(p *HeaderPolicy) {
+	headerPolicy.Store(p)
+}
+
+// ApplyHeaders returns a transport adding the headers of the HeaderPolicy
+// given to SetHeaderPolicy to requests. HTTPClient already adds them, so
+// this is only needed by sources using their own clients.
+func ApplyHeaders(transport http.RoundTripper) http.RoundTripper {
+	return &headersTransport{RoundTripper: transport}
+}
+
+// headersTransport adds the headers of the HeaderPolicy to requests.
+type headersTransport struct {
+	http.RoundTripper
+}
+
+// RoundTrip makes a copy of the request with the headers of the policy added.
+func (t *headersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	policy := headerPolicy.Load()
+	if policy == nil {
+		policy = defaultHeaderPolicy
+	}
+	req = req.Clone(req.Context())
+	policy.Apply(req)
+	return t.RoundTripper.RoundTrip(req)
+}
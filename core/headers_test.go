@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoadHeaderPolicy(t *testing.T) {
+	policy, err := LoadHeaderPolicy(strings.NewReader(`{
+		"user_agent": "subzero/1.0",
+		"headers": {"X-Bug-Bounty": "username"},
+		"sources": {"Bing": {"user_agent": "bing/1.0", "headers": {"X-Bug-Bounty": "other"}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if policy.UserAgent != "subzero/1.0" || policy.Headers["X-Bug-Bounty"] != "username" || policy.Sources["Bing"].UserAgent != "bing/1.0" {
+		t.Fatalf("unexpected policy: %+v", policy)
+	}
+
+	if _, err := LoadHeaderPolicy(strings.NewReader(`{"useragent": "subzero/1.0"}`)); err == nil {
+		t.Fatal("expected an error for an unknown field")
+	}
+}
+
+func TestHeaderPolicy_Apply(t *testing.T) {
+	policy := &HeaderPolicy{
+		UserAgent: "subzero/1.0",
+		Headers:   map[string]string{"x-bug-bounty": "username", "Authorization": "none"},
+		Sources:   map[string]*SourceHeaders{"Bing": {UserAgent: "bing/1.0", Headers: map[string]string{"X-Bug-Bounty": "other"}}},
+	}
+
+	apply := func(source string, header http.Header) http.Header {
+		ctx := context.Background()
+		if source != "" {
+			ctx = withSourceStats(ctx, &sourceCounters{name: source})
+		}
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		req = req.WithContext(ctx)
+		policy.Apply(req)
+		return req.Header
+	}
+
+	header := apply("Ask", http.Header{"Authorization": {"Bearer s3cret"}})
+	if header.Get("User-Agent") != "subzero/1.0" || header.Get("X-Bug-Bounty") != "username" || header.Get("Authorization") != "Bearer s3cret" {
+		t.Fatalf("expected the policy to be added to the headers of the source, got %v", header)
+	}
+
+	header = apply("Bing", nil)
+	if header.Get("User-Agent") != "bing/1.0" || header.Get("X-Bug-Bounty") != "other" {
+		t.Fatalf("expected the overrides of the source, got %v", header)
+	}
+
+	if header = apply("", http.Header{"User-Agent": {"own/1.0"}}); header.Get("User-Agent") != "own/1.0" {
+		t.Fatalf("expected the User-Agent of the request to be kept, got %v", header)
+	}
+}
+
+func TestHeaderPolicy_RotateUserAgents(t *testing.T) {
+	policy := &HeaderPolicy{RotateUserAgents: true, UserAgents: []string{"one/1.0", "two/1.0"}}
+	used := []string{}
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+		policy.Apply(req)
+		used = append(used, req.Header.Get("User-Agent"))
+	}
+	if strings.Join(used, " ") != "one/1.0 two/1.0 one/1.0" {
+		t.Fatalf("expected every request to use the next User-Agent, got %v", used)
+	}
+
+	policy = &HeaderPolicy{RotateUserAgents: true}
+	if userAgent := policy.userAgent(""); userAgent != BrowserUserAgents[0] {
+		t.Fatalf("expected the browser User-Agents by default, got %v", userAgent)
+	}
+}
+
+func TestSetHeaderPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Header.Get("User-Agent")+" "+r.Header.Get("X-Bug-Bounty"))
+	}))
+	defer server.Close()
+
+	get := func() string {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := HTTPClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		// the request given isn't changed
+		if req.Header.Get("User-Agent") != "" {
+			t.Fatal("expected the headers to be added to a copy of the request")
+		}
+		return string(body)
+	}
+
+	if body := get(); body != DefaultUserAgent+" " {
+		t.Fatalf("expected the default User-Agent, got '%v'", body)
+	}
+
+	SetHeaderPolicy(&HeaderPolicy{UserAgent: "subzero/1.0", Headers: map[string]string{"X-Bug-Bounty": "username"}})
+	defer SetHeaderPolicy(nil)
+	if body := get(); body != "subzero/1.0 username" {
+		t.Fatalf("expected the headers of the policy, got '%v'", body)
+	}
+}
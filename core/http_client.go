@@ -11,9 +11,13 @@ import (
 
 var dnsCache = dnscache.New(5 * time.Minute)
 
-// HTTPClient is a reusable component that can be used in sources.
+// HTTPClient is a reusable component that can be used in sources. The requests
+// made with it have the headers of the policy given to SetHeaderPolicy, are
+// counted in the Stats of the source in their context, and are made through
+// the proxies given to SetProxies, if any.
 var HTTPClient = &http.Client{
-	Transport: &http.Transport{
+	Transport: ApplyHeaders(CountRequests(&http.Transport{
+		Proxy: Proxy,
 		Dial: func(network string, address string) (net.Conn, error) {
 			separator := strings.LastIndex(address, ":")
 			ip, err := dnsCache.FetchOneString(address[:separator])
@@ -34,7 +38,7 @@ var HTTPClient = &http.Client{
 		IdleConnTimeout:       10 * time.Second,
 		ResponseHeaderTimeout: 10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
-	},
+	})),
 }
 
 // var HTTPClient = &http.Client{
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// LevelTrace is the level of the most detailed logs, like every page a source fetches.
+const LevelTrace = slog.LevelDebug - 4
+
+// The formats of NewLogger.
+const (
+	LogText = "text"
+	LogJSON = "json"
+)
+
+// loggerKey is the context key of the logger of WithLogger.
+type loggerKey struct{}
+
+// discardLogger is used when no logger was given.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// WithLogger returns a context carrying the given logger, which sources log to.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// Logger returns the logger of the context, or one discarding everything.
+// Within EnumerateSubdomains it's the logger of the EnumerationOptions, with
+// the source and domain as attributes.
+func Logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return discardLogger
+}
+
+// ParseLogLevel parses a level name, one of trace, debug, info, warn or error.
+func ParseLogLevel(name string) (slog.Level, error) {
+	if strings.EqualFold(name, "trace") {
+		return LevelTrace, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q, expected trace, debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// NewLogger returns a logger writing records of at least the given level in
+// the text or JSON format, naming LevelTrace TRACE. Secrets in the values
+// logged are redacted, like with RedactString and RedactError.
+func NewLogger(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if attr.Key == slog.LevelKey && len(groups) == 0 {
+				if level, ok := attr.Value.Any().(slog.Level); ok && level <= LevelTrace {
+					attr.Value = slog.StringValue("TRACE")
+				}
+			}
+			// values logged by sources could include URLs with API keys
+			switch value := attr.Value.Any().(type) {
+			case string:
+				attr.Value = slog.StringValue(RedactString(value))
+			case error:
+				attr.Value = slog.AnyValue(RedactError(value))
+			}
+			return attr
+		},
+	}
+
+	switch format {
+	case LogText, "":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case LogJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, expected text or json", format)
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLevel(t *testing.T) {
+	var units = []struct {
+		name string
+		exp  slog.Level
+	}{
+		{"trace", LevelTrace},
+		{"DEBUG", slog.LevelDebug},
+		{"info", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+	for _, u := range units {
+		if level, err := ParseLogLevel(u.name); err != nil || level != u.exp {
+			t.Fatalf("expected %v for '%v', got %v (%v)", u.exp, u.name, level, err)
+		}
+	}
+	if _, err := ParseLogLevel("loud"); err == nil {
+		t.Fatal("expected an error for an unknown level")
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	output := &bytes.Buffer{}
+	logger, err := NewLogger(output, LogJSON, LevelTrace)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Log(context.Background(), LevelTrace, "fetching page", "page", 2)
+
+	record := map[string]interface{}{}
+	if err := json.Unmarshal(output.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["level"] != "TRACE" || record["msg"] != "fetching page" || record["page"] != 2.0 {
+		t.Fatalf("unexpected record: %v", record)
+	}
+
+	if _, err := NewLogger(output, "xml", slog.LevelInfo); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestEnumerateSubdomains_Logger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "www.example.com\n")
+	}))
+	defer server.Close()
+
+	output := &bytes.Buffer{}
+	logger, _ := NewLogger(output, LogText, slog.LevelDebug)
+	options := &EnumerationOptions{Sources: []Source{&httpSource{URL: server.URL + "/?apikey=s3cret"}}, Logger: logger}
+	for range EnumerateSubdomains(context.Background(), "example.com", options) {
+	}
+
+	logs := output.String()
+	for _, expected := range []string{
+		"msg=\"source started\" source=httpSource domain=example.com",
+		"msg=request source=httpSource domain=example.com method=GET url=\"" + server.URL + "/?apikey=REDACTED\" status=200",
+		"msg=\"source error\" source=httpSource domain=example.com error=\"503 Service Unavailable\" kind=http_status",
+		"msg=\"source finished\" source=httpSource domain=example.com",
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("expected '%v' in the logs:\n%v", expected, logs)
+		}
+	}
+	if strings.Contains(logs, "s3cret") {
+		t.Fatalf("expected the API key to be redacted:\n%v", logs)
+	}
+}
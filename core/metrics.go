@@ -0,0 +1,211 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsBuckets are the upper bounds in seconds of the buckets of the histograms.
+var metricsBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// histogram counts observations in metricsBuckets.
+type histogram struct {
+	buckets []uint64
+	count   uint64
+	sum     float64
+}
+
+func (h *histogram) observe(value float64) {
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(metricsBuckets))
+	}
+	for i, bound := range metricsBuckets {
+		if value <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+// Metrics collects the metrics of sources for long running enumerations, and
+// serves them in the Prometheus text format. It's used by setting it as the
+// Metrics of EnumerationOptions, or with WithSource and Record for sources
+// used directly, like a Monitor.
+type Metrics struct {
+	mutex     sync.Mutex
+	requests  map[string]uint64     // By source.
+	responses map[[2]string]uint64  // By source and status code.
+	results   map[string]uint64     // By source.
+	errors    map[[2]string]uint64  // By source and ErrorKind.
+	findings  map[string]uint64     // By root domain.
+	latency   map[string]*histogram // By source.
+	waits     map[string]*histogram // By source.
+	inFlight  int64
+}
+
+// NewMetrics returns empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{
+		requests:  map[string]uint64{},
+		responses: map[[2]string]uint64{},
+		results:   map[string]uint64{},
+		errors:    map[[2]string]uint64{},
+		findings:  map[string]uint64{},
+		latency:   map[string]*histogram{},
+		waits:     map[string]*histogram{},
+	}
+}
+
+// WithSource returns a context in which the requests and waits of the
+// source with the given name are counted in the metrics.
+func (m *Metrics) WithSource(ctx context.Context, source string) context.Context {
+	return withSourceStats(ctx, &sourceCounters{name: source, metrics: m, names: map[string]bool{}, errors: map[string]int{}})
+}
+
+// Record counts a result of the source with the given name.
+func (m *Metrics) Record(source string, result *Result) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if result.IsFailure() {
+		m.errors[[2]string{source, ErrorKind(result.GetFailure())}]++
+		return
+	}
+	if !result.IsSuccess() {
+		return
+	}
+	m.results[source]++
+	if domain := result.GetDomain(); domain != "" {
+		m.findings[NormalizeName(domain)]++
+	}
+}
+
+// request counts a request of the source, with the status code of its response or 0 if it failed.
+func (m *Metrics) request(source string, status int, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.requests[source]++
+	if status != 0 {
+		m.responses[[2]string{source, strconv.Itoa(status)}]++
+	}
+	if m.latency[source] == nil {
+		m.latency[source] = &histogram{}
+	}
+	m.latency[source].observe(latency.Seconds())
+}
+
+// wait counts the time the source waited for its rate limit.
+func (m *Metrics) wait(source string, wait time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.waits[source] == nil {
+		m.waits[source] = &histogram{}
+	}
+	m.waits[source].observe(wait.Seconds())
+}
+
+// enumerating adds the given number to the enumerations in flight.
+func (m *Metrics) enumerating(delta int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.inFlight += delta
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	out := &strings.Builder{}
+
+	writeCounters(out, "subzero_source_requests_total", "HTTP requests made by each source.", []string{"source"}, singleLabel(m.requests))
+	writeCounters(out, "subzero_source_responses_total", "HTTP responses received by each source, by status code.", []string{"source", "code"}, pairLabels(m.responses))
+	writeCounters(out, "subzero_source_results_total", "Names found by each source.", []string{"source"}, singleLabel(m.results))
+	writeCounters(out, "subzero_source_errors_total", "Errors hit by each source, by kind.", []string{"source", "kind"}, pairLabels(m.errors))
+	writeHistograms(out, "subzero_source_request_duration_seconds", "Time until the headers of the HTTP responses of each source.", m.latency)
+	writeHistograms(out, "subzero_source_wait_duration_seconds", "Time each source waited for its rate limit.", m.waits)
+	fmt.Fprintln(out, "# HELP subzero_enumerations_in_flight Enumerations of a domain running, including recursive ones.")
+	fmt.Fprintln(out, "# TYPE subzero_enumerations_in_flight gauge")
+	fmt.Fprintln(out, "subzero_enumerations_in_flight", m.inFlight)
+	writeCounters(out, "subzero_findings_total", "Names found for each root domain.", []string{"domain"}, singleLabel(m.findings))
+
+	n, err := io.WriteString(w, out.String())
+	return int64(n), err
+}
+
+// labelValues are the values of a counter by the values of its labels.
+type labelValues map[string]uint64
+
+// labelSeparator joins the values of several labels in the keys of labelValues.
+const labelSeparator = "\x00"
+
+func singleLabel(values map[string]uint64) labelValues {
+	return labelValues(values)
+}
+
+func pairLabels(values map[[2]string]uint64) labelValues {
+	joined := labelValues{}
+	for labels, value := range values {
+		joined[labels[0]+labelSeparator+labels[1]] = value
+	}
+	return joined
+}
+
+// formatLabels formats the names and values of labels, like {source="CrtSh"}.
+func formatLabels(names []string, values []string) string {
+	labels := []string{}
+	for i, name := range names {
+		labels = append(labels, name+`="`+escapeLabelValue(values[i])+`"`)
+	}
+	return "{" + strings.Join(labels, ",") + "}"
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func writeCounters(out *strings.Builder, name, help string, labels []string, values labelValues) {
+	fmt.Fprintf(out, "# HELP %v %v\n# TYPE %v counter\n", name, help, name)
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(out, "%v%v %d\n", name, formatLabels(labels, strings.Split(key, labelSeparator)), values[key])
+	}
+}
+
+func writeHistograms(out *strings.Builder, name, help string, histograms map[string]*histogram) {
+	fmt.Fprintf(out, "# HELP %v %v\n# TYPE %v histogram\n", name, help, name)
+	sources := []string{}
+	for source := range histograms {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		h := histograms[source]
+		for i, bound := range metricsBuckets {
+			fmt.Fprintf(out, "%v_bucket%v %d\n", name, formatLabels([]string{"source", "le"}, []string{source, strconv.FormatFloat(bound, 'g', -1, 64)}), h.buckets[i])
+		}
+		fmt.Fprintf(out, "%v_bucket%v %d\n", name, formatLabels([]string{"source", "le"}, []string{source, "+Inf"}), h.count)
+		fmt.Fprintf(out, "%v_sum%v %v\n", name, formatLabels([]string{"source"}, []string{source}), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "%v_count%v %d\n", name, formatLabels([]string{"source"}, []string{source}), h.count)
+	}
+}
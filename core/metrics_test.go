@@ -0,0 +1,72 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "www.example.com\n")
+	}))
+	defer server.Close()
+
+	metrics := NewMetrics()
+	options := &EnumerationOptions{Sources: []Source{&countingSource{Fail: true}, &httpSource{URL: server.URL}}, Metrics: metrics}
+	for range EnumerateSubdomains(context.Background(), "example.com", options) {
+	}
+
+	// sources used directly are counted through their context
+	ctx := metrics.WithSource(context.Background(), "Certstream")
+	RecordWait(ctx, 2*time.Second)
+	metrics.Record("Certstream", NewResult("certstream", nil, context.DeadlineExceeded))
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type '%v'", contentType)
+	}
+
+	body := recorder.Body.String()
+	for _, line := range []string{
+		"# TYPE subzero_source_requests_total counter",
+		`subzero_source_requests_total{source="httpSource"} 1`,
+		`subzero_source_responses_total{source="httpSource",code="200"} 1`,
+		`subzero_source_results_total{source="countingSource"} 2`,
+		`subzero_source_results_total{source="httpSource"} 2`,
+		`subzero_source_errors_total{source="countingSource",kind="http_status"} 1`,
+		`subzero_source_errors_total{source="httpSource",kind="http_status"} 1`,
+		`subzero_source_errors_total{source="Certstream",kind="timeout"} 1`,
+		"# TYPE subzero_source_request_duration_seconds histogram",
+		`subzero_source_request_duration_seconds_bucket{source="httpSource",le="+Inf"} 1`,
+		`subzero_source_request_duration_seconds_count{source="httpSource"} 1`,
+		`subzero_source_wait_duration_seconds_bucket{source="Certstream",le="1"} 0`,
+		`subzero_source_wait_duration_seconds_bucket{source="Certstream",le="2.5"} 1`,
+		`subzero_source_wait_duration_seconds_sum{source="Certstream"} 2`,
+		"subzero_enumerations_in_flight 0",
+		`subzero_findings_total{domain="example.com"} 4`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("expected '%v' in the metrics:\n%v", line, body)
+		}
+	}
+}
+
+func TestMetrics_EscapesLabels(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.Record(`a"b\c`, NewResult("test", nil, errors.New("failed")))
+
+	body := &strings.Builder{}
+	if _, err := metrics.WriteTo(body); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(body.String(), `subzero_source_errors_total{source="a\"b\\c",kind="other"} 1`) {
+		t.Fatalf("expected the label to be escaped:\n%v", body)
+	}
+}
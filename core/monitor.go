@@ -0,0 +1,10 @@
+package core
+
+import "context"
+
+// Monitor defines the minimum interface any module which continuously
+// watches for new subdomains should follow. Unlike a Source, the results
+// channel is only closed once the given context is canceled.
+type Monitor interface {
+	Monitor(context.Context, []string) <-chan *Result
+}
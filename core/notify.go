@@ -0,0 +1,324 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notifier sends out new findings.
+type Notifier interface {
+	Notify(context.Context, []*Result) error
+}
+
+// The payload formats supported by Webhook.
+const (
+	WebhookSlack   = "slack"   // A Slack incoming webhook message, {"text": "..."}.
+	WebhookDiscord = "discord" // A Discord webhook message, {"content": "..."}.
+	WebhookJSON    = "json"    // A generic {"results": [OutputRecord, ...]} object.
+)
+
+// discordMessageLimit is the maximum length of a Discord message's content.
+const discordMessageLimit = 2000
+
+// webhookClient posts to webhooks, without the User-Agent, headers and proxies
+// HTTPClient uses for the requests of sources.
+var webhookClient = &http.Client{Timeout: time.Minute}
+
+// Webhook is a Notifier which posts the findings to a webhook URL.
+type Webhook struct {
+	URL        string
+	Format     string       // One of WebhookSlack, WebhookDiscord or WebhookJSON, defaults to WebhookJSON.
+	HTTPClient *http.Client // Defaults to a plain client, not HTTPClient.
+}
+
+// notificationText summarizes the given findings as a message, grouped by root domain.
+func notificationText(results []*Result) string {
+	domains := map[string][]string{}
+	for _, result := range results {
+		record := NewOutputRecord(result)
+		domains[record.Domain] = append(domains[record.Domain], record.Subdomain)
+	}
+
+	names := []string{}
+	for domain := range domains {
+		names = append(names, domain)
+	}
+	sort.Strings(names)
+
+	plural := "s"
+	if len(results) == 1 {
+		plural = ""
+	}
+
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "subzero found %d new subdomain%s", len(results), plural)
+	for _, domain := range names {
+		sort.Strings(domains[domain])
+		fmt.Fprintf(&buffer, "\n*%s*: %s", domain, strings.Join(domains[domain], ", "))
+	}
+	return buffer.String()
+}
+
+// splitMessage splits the given text into messages of at most limit bytes,
+// between lines where possible, or else between the names of a line.
+func splitMessage(text string, limit int) []string {
+	messages := []string{}
+	for len(text) > limit {
+		// the newline or comma split at is left out
+		cut, skip := strings.LastIndex(text[:limit+1], "\n"), 1
+		if cut <= 0 {
+			cut, skip = strings.LastIndex(text[:limit+1], ", "), 2
+		}
+		if cut <= 0 {
+			cut, skip = limit, 0
+		}
+		messages = append(messages, text[:cut])
+		text = text[cut+skip:]
+	}
+	return append(messages, text)
+}
+
+// payloads creates the bodies posted to the webhook, which is more than one
+// when the findings don't fit in a single Discord message.
+func (webhook *Webhook) payloads(results []*Result) ([]interface{}, error) {
+	switch webhook.Format {
+	case WebhookSlack:
+		return []interface{}{map[string]string{"text": notificationText(results)}}, nil
+	case WebhookDiscord:
+		payloads := []interface{}{}
+		for _, text := range splitMessage(notificationText(results), discordMessageLimit) {
+			payloads = append(payloads, map[string]string{"content": text})
+		}
+		return payloads, nil
+	case WebhookJSON, "":
+		records := []*OutputRecord{}
+		for _, result := range results {
+			records = append(records, NewOutputRecord(result))
+		}
+		return []interface{}{map[string]interface{}{"results": records}}, nil
+	}
+	return nil, fmt.Errorf("unknown webhook format %q", webhook.Format)
+}
+
+// redactedURL returns the webhook URL without its path, which holds the secret
+// token of Slack and Discord webhooks, so it can be shown in errors.
+func (webhook *Webhook) redactedURL() string {
+	u, err := url.Parse(webhook.URL)
+	if err != nil {
+		return Redacted
+	}
+	return u.Scheme + "://" + u.Host + "/" + Redacted
+}
+
+// Notify posts the given findings to the webhook URL, whose path is added with
+// AddSecret as it holds the token of Slack and Discord webhooks.
+func (webhook *Webhook) Notify(ctx context.Context, results []*Result) error {
+	if u, err := url.Parse(webhook.URL); err == nil && len(u.Path) > 1 {
+		AddSecret(u.Path)
+	}
+
+	payloads, err := webhook.payloads(results)
+	if err != nil {
+		return err
+	}
+
+	for _, payload := range payloads {
+		if err := webhook.post(ctx, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// post sends a single payload to the webhook URL.
+func (webhook *Webhook) post(ctx context.Context, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL %v", webhook.redactedURL())
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := webhook.HTTPClient
+	if client == nil {
+		client = webhookClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			urlErr.URL = webhook.redactedURL()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%v: %v", webhook.redactedURL(), resp.Status)
+	}
+
+	return nil
+}
+
+// BatchNotifier is a Notifier which queues findings and sends them to the wrapped
+// Notifier in the background, in batches of at most MaxBatch and at most once every
+// Interval. Notify never blocks, so a slow or failing Notifier doesn't hold anything
+// up; failed batches are handed to OnError and dropped.
+type BatchNotifier struct {
+	Notifier Notifier
+	Interval time.Duration   // Minimum time between batches, defaults to 1 minute.
+	MaxBatch int             // Maximum number of findings per batch, defaults to 100.
+	Timeout  time.Duration   // Timeout for sending a batch, defaults to 30 seconds.
+	OnError  func(err error) // Called with every failure, if set.
+	queue    []*Result
+	mutex    sync.Mutex
+	wake     chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	once     sync.Once
+	stop     sync.Once
+}
+
+func (b *BatchNotifier) init() {
+	b.once.Do(func() {
+		if b.Interval <= 0 {
+			b.Interval = time.Minute
+		}
+		if b.MaxBatch <= 0 {
+			b.MaxBatch = 100
+		}
+		if b.Timeout <= 0 {
+			b.Timeout = 30 * time.Second
+		}
+		b.wake = make(chan struct{}, 1)
+		b.done = make(chan struct{})
+		b.stopped = make(chan struct{})
+		go b.loop()
+	})
+}
+
+// next takes the next batch off the queue.
+func (b *BatchNotifier) next() []*Result {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	size := len(b.queue)
+	if size > b.MaxBatch {
+		size = b.MaxBatch
+	}
+	batch := b.queue[:size:size]
+	b.queue = b.queue[size:]
+	return batch
+}
+
+func (b *BatchNotifier) send(batch []*Result) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout)
+	defer cancel()
+	if err := b.Notifier.Notify(ctx, batch); err != nil && b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+func (b *BatchNotifier) loop() {
+	defer close(b.stopped)
+
+	for {
+		select {
+		case <-b.wake:
+		case <-b.done:
+			// send whatever is left, without waiting in between
+			for batch := b.next(); len(batch) > 0; batch = b.next() {
+				b.send(batch)
+			}
+			return
+		}
+
+		batch := b.next()
+		if len(batch) == 0 {
+			continue
+		}
+		b.send(batch)
+
+		// throttle, while still collecting findings for the next batch
+		select {
+		case <-time.After(b.Interval):
+		case <-b.done:
+		}
+
+		b.mutex.Lock()
+		if len(b.queue) > 0 {
+			select {
+			case b.wake <- struct{}{}:
+			default:
+			}
+		}
+		b.mutex.Unlock()
+	}
+}
+
+// Notify queues the given findings to be sent, without blocking.
+func (b *BatchNotifier) Notify(ctx context.Context, results []*Result) error {
+	b.init()
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	b.mutex.Lock()
+	b.queue = append(b.queue, results...)
+	b.mutex.Unlock()
+
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Close sends every queued finding, waiting until done or the given context is canceled.
+func (b *BatchNotifier) Close(ctx context.Context) error {
+	b.init()
+	b.stop.Do(func() { close(b.done) })
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// multiNotifier sends to every Notifier.
+type multiNotifier []Notifier
+
+func (notifiers multiNotifier) Notify(ctx context.Context, results []*Result) error {
+	var firstErr error
+	for _, notifier := range notifiers {
+		if err := notifier.Notify(ctx, results); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// MultiNotifier creates a Notifier which sends to every given Notifier,
+// returning the first error once all of them have been tried.
+func MultiNotifier(notifiers ...Notifier) Notifier {
+	return multiNotifier(notifiers)
+}
@@ -0,0 +1,200 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWebhookReceiver records the bodies posted to it.
+type fakeWebhookReceiver struct {
+	sync.Mutex
+	bodies []string
+	status int
+}
+
+func (receiver *fakeWebhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	receiver.Lock()
+	defer receiver.Unlock()
+	receiver.bodies = append(receiver.bodies, string(body))
+	if receiver.status != 0 {
+		w.WriteHeader(receiver.status)
+	}
+}
+
+func (receiver *fakeWebhookReceiver) received() []string {
+	receiver.Lock()
+	defer receiver.Unlock()
+	return append([]string{}, receiver.bodies...)
+}
+
+func newNotifyTestResults(names ...string) []*Result {
+	results := []*Result{}
+	for _, name := range names {
+		result := &Result{Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Type: "crtsh", Success: name, Domain: "example.com"}
+		results = append(results, result)
+	}
+	return results
+}
+
+func TestWebhook(t *testing.T) {
+	receiver := &fakeWebhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	results := newNotifyTestResults("b.example.com", "a.example.com")
+
+	var units = []struct {
+		format string
+		exp    string
+	}{
+		{WebhookSlack, `{"text":"subzero found 2 new subdomains\n*example.com*: a.example.com, b.example.com"}`},
+		{WebhookDiscord, `{"content":"subzero found 2 new subdomains\n*example.com*: a.example.com, b.example.com"}`},
+		{WebhookJSON, `{"results":[{"timestamp":"2020-01-02T03:04:05Z","domain":"example.com","source":"crtsh","subdomain":"b.example.com"},{"timestamp":"2020-01-02T03:04:05Z","domain":"example.com","source":"crtsh","subdomain":"a.example.com"}]}`},
+	}
+	for i, u := range units {
+		webhook := &Webhook{URL: server.URL, Format: u.format}
+		if err := webhook.Notify(context.Background(), results); err != nil {
+			t.Fatal(err)
+		}
+		if got := receiver.received()[i]; got != u.exp {
+			t.Fatalf("expected '%v', got '%v'", u.exp, got)
+		}
+	}
+
+	receiver.status = http.StatusInternalServerError
+	if err := (&Webhook{URL: server.URL}).Notify(context.Background(), results); err == nil {
+		t.Fatal("expected an error for a failed request")
+	}
+
+	if err := (&Webhook{URL: server.URL, Format: "irc"}).Notify(context.Background(), results); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestWebhook_DiscordLimit(t *testing.T) {
+	receiver := &fakeWebhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	names := []string{}
+	results := newNotifyTestResults()
+	for i := 0; i < 200; i++ {
+		name := strings.Repeat("a", 20) + strconv.Itoa(i) + ".example.com"
+		names = append(names, name)
+		results = append(results, newNotifyTestResults(name)...)
+	}
+	results = append(results, &Result{Type: "crtsh", Success: "www.example.org", Domain: "example.org"})
+
+	webhook := &Webhook{URL: server.URL, Format: WebhookDiscord}
+	if err := webhook.Notify(context.Background(), results); err != nil {
+		t.Fatal(err)
+	}
+
+	// every name is sent, over several messages within the limit
+	content := ""
+	for _, body := range receiver.received() {
+		message := map[string]string{}
+		json.Unmarshal([]byte(body), &message)
+		if len(message["content"]) > discordMessageLimit {
+			t.Fatalf("expected at most %d characters, got %d", discordMessageLimit, len(message["content"]))
+		}
+		content += message["content"] + "\n"
+	}
+	if len(receiver.received()) < 3 {
+		t.Fatalf("expected the findings to be split over several messages, got %d", len(receiver.received()))
+	}
+	for _, name := range append(names, "www.example.org") {
+		if !strings.Contains(content, name+",") && !strings.Contains(content, name+"\n") {
+			t.Fatalf("expected %v to be sent", name)
+		}
+	}
+}
+
+func TestWebhook_Errors(t *testing.T) {
+	receiver := &fakeWebhookReceiver{status: http.StatusNotFound}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	// the token in the path of the webhook URL isn't shown in errors
+	webhook := &Webhook{URL: server.URL + "/api/webhooks/123/s3cret", Format: WebhookDiscord}
+	err := webhook.Notify(context.Background(), newNotifyTestResults("www.example.com"))
+	if err == nil || strings.Contains(err.Error(), "s3cret") {
+		t.Fatalf("expected an error without the token, got '%v'", err)
+	}
+
+	server.Close()
+	err = webhook.Notify(context.Background(), newNotifyTestResults("www.example.com"))
+	if err == nil || strings.Contains(err.Error(), "s3cret") || !strings.Contains(err.Error(), server.Listener.Addr().String()) {
+		t.Fatalf("expected an error with only the host, got '%v'", err)
+	}
+}
+
+// blockingNotifier fails every batch after holding it for a while.
+type blockingNotifier struct {
+	sync.Mutex
+	batches [][]*Result
+}
+
+func (notifier *blockingNotifier) Notify(ctx context.Context, results []*Result) error {
+	time.Sleep(50 * time.Millisecond)
+	notifier.Lock()
+	defer notifier.Unlock()
+	notifier.batches = append(notifier.batches, results)
+	return errors.New("unavailable")
+}
+
+func TestBatchNotifier(t *testing.T) {
+	wrapped := &blockingNotifier{}
+	failures := 0
+	mutex := sync.Mutex{}
+
+	notifier := &BatchNotifier{
+		Notifier: wrapped,
+		Interval: 100 * time.Millisecond,
+		MaxBatch: 2,
+		OnError: func(err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			failures++
+		},
+	}
+
+	// queuing never waits on the slow, failing notifier
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		notifier.Notify(context.Background(), newNotifyTestResults("a.example.com"))
+	}
+	if time.Since(start) > 25*time.Millisecond {
+		t.Fatalf("expected Notify to not block, took %v", time.Since(start))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for _, batch := range wrapped.batches {
+		if len(batch) > 2 {
+			t.Fatalf("expected batches of at most 2, got %d", len(batch))
+		}
+		total += len(batch)
+	}
+	if total != 5 {
+		t.Fatalf("expected all 5 findings to be sent, got %d", total)
+	}
+	if failures != len(wrapped.batches) {
+		t.Fatalf("expected %d failures, got %d", len(wrapped.batches), failures)
+	}
+}
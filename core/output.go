@@ -0,0 +1,263 @@
+package core
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The output formats supported by NewResultWriter.
+const (
+	OutputPlain = "plain" // One name per line, optionally prefixed with the source.
+	OutputJSONL = "jsonl" // One OutputRecord JSON object per line.
+	OutputCSV   = "csv"   // A header row followed by one row per OutputRecord, see OutputCSVHeader.
+	OutputJSON  = "json"  // A single JSON object of OutputRecords grouped by root domain and source.
+)
+
+// OutputFormats lists every supported output format.
+var OutputFormats = []string{OutputPlain, OutputJSONL, OutputCSV, OutputJSON}
+
+// OutputCSVHeader is the header row of the CSV output format. The takeover
+// column holds the takeover description, and the probes column holds the
+// final URLs of every probe separated by spaces.
+var OutputCSVHeader = []string{"timestamp", "domain", "source", "subdomain", "related", "error", "takeover", "probes"}
+
+// OutputRecord is the stable schema of a Result used by the structured output formats.
+// Fields may be added over time, but existing ones won't be renamed or removed.
+type OutputRecord struct {
+	Timestamp   time.Time    `json:"timestamp"`             // When the Result was found, in UTC.
+	Domain      string       `json:"domain"`                // The root domain being enumerated.
+	Source      string       `json:"source"`                // The source which found the Result.
+	Subdomain   string       `json:"subdomain,omitempty"`   // The name found, empty for errors.
+	Related     bool         `json:"related,omitempty"`     // Whether the name belongs to another apex domain.
+	Error       string       `json:"error,omitempty"`       // The error string, for failed Results.
+	Certificate *Certificate `json:"certificate,omitempty"` // The certificate the name was found on.
+	Takeover    *Takeover    `json:"takeover,omitempty"`    // The outcome of a takeover check.
+	Probes      []*Probe     `json:"probes,omitempty"`      // The outcome of HTTP(S) probing.
+}
+
+// NewOutputRecord creates the OutputRecord for the given Result.
+func NewOutputRecord(r *Result) *OutputRecord {
+	r.RLock()
+	defer r.RUnlock()
+
+	record := &OutputRecord{
+		Timestamp:   r.Timestamp,
+		Domain:      r.Domain,
+		Source:      r.Type,
+		Certificate: r.Certificate,
+		Takeover:    r.Takeover,
+		Probes:      r.Probes,
+	}
+
+	if r.Failure != nil {
+		record.Error = RedactString(r.Failure.Error())
+	} else if related, ok := r.Success.(RelatedDomain); ok {
+		record.Subdomain = string(related)
+		record.Related = true
+	} else if r.Success != nil {
+		record.Subdomain = fmt.Sprintf("%v", r.Success)
+	}
+
+	return record
+}
+
+// Result recreates the Result the OutputRecord was made from, with
+// its error reduced to the error string.
+func (record *OutputRecord) Result() *Result {
+	r := &Result{
+		Timestamp:   record.Timestamp,
+		Type:        record.Source,
+		Domain:      record.Domain,
+		Certificate: record.Certificate,
+		Takeover:    record.Takeover,
+		Probes:      record.Probes,
+	}
+	switch {
+	case record.Error != "":
+		r.Failure = errors.New(record.Error)
+	case record.Related:
+		r.Success = RelatedDomain(record.Subdomain)
+	default:
+		r.Success = record.Subdomain
+	}
+	return r
+}
+
+// CSV returns the OutputRecord as a row matching OutputCSVHeader.
+func (record *OutputRecord) CSV() []string {
+	takeover := ""
+	if record.Takeover != nil {
+		takeover = record.Takeover.String()
+	}
+
+	probes := []string{}
+	for _, probe := range record.Probes {
+		probes = append(probes, probe.FinalURL)
+	}
+
+	return []string{
+		record.Timestamp.Format(time.RFC3339Nano),
+		record.Domain,
+		record.Source,
+		record.Subdomain,
+		strconv.FormatBool(record.Related),
+		record.Error,
+		takeover,
+		strings.Join(probes, " "),
+	}
+}
+
+// ResultWriter writes Results in one of the output formats. Flush must
+// be called once done, as some formats only write on Flush.
+type ResultWriter interface {
+	WriteResult(*Result) error
+	Flush() error
+}
+
+// NormalizeOutputFormat returns the given output format with its aliases, the
+// "plaintext" of older versions and an empty one, replaced by OutputPlain.
+func NormalizeOutputFormat(format string) string {
+	switch format {
+	case "plaintext", "":
+		return OutputPlain
+	}
+	return format
+}
+
+// NewResultWriter creates a ResultWriter for the given output format. The labels
+// option only applies to the plain format, which prefixes each name with its source.
+func NewResultWriter(w io.Writer, format string, labels bool) (ResultWriter, error) {
+	switch NormalizeOutputFormat(format) {
+	case OutputPlain:
+		return &plainResultWriter{w: w, labels: labels}, nil
+	case OutputJSONL:
+		return &jsonlResultWriter{encoder: json.NewEncoder(w)}, nil
+	case OutputCSV:
+		return &csvResultWriter{w: csv.NewWriter(w)}, nil
+	case OutputJSON:
+		return &jsonResultWriter{w: w, domains: map[string]map[string][]*OutputRecord{}}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, expected one of %v", format, strings.Join(OutputFormats, ", "))
+}
+
+type plainResultWriter struct {
+	w      io.Writer
+	labels bool
+}
+
+func (writer *plainResultWriter) WriteResult(r *Result) error {
+	line := []interface{}{}
+	if writer.labels {
+		line = append(line, r.GetType())
+	}
+
+	if r.IsFailure() {
+		line = append(line, r.GetFailure())
+	} else if r.IsRelatedDomain() {
+		line = append(line, "related", r.GetSuccess())
+	} else {
+		line = append(line, r.GetSuccess())
+		for _, probe := range r.GetProbes() {
+			line = append(line, probe)
+		}
+		if takeover := r.GetTakeover(); takeover != nil {
+			line = append(line, takeover)
+		}
+	}
+
+	_, err := fmt.Fprintln(writer.w, line...)
+	return err
+}
+
+func (writer *plainResultWriter) WriteDiffEntry(entry *DiffEntry) error {
+	prefix := "+"
+	if entry.Change == DiffRemoved {
+		prefix = "-"
+	}
+	_, err := fmt.Fprintln(writer.w, prefix, entry.Name)
+	return err
+}
+
+func (writer *plainResultWriter) Flush() error {
+	return nil
+}
+
+type jsonlResultWriter struct {
+	encoder *json.Encoder
+}
+
+func (writer *jsonlResultWriter) WriteResult(r *Result) error {
+	return writer.encoder.Encode(NewOutputRecord(r))
+}
+
+func (writer *jsonlResultWriter) WriteDiffEntry(entry *DiffEntry) error {
+	return writer.encoder.Encode(entry)
+}
+
+func (writer *jsonlResultWriter) Flush() error {
+	return nil
+}
+
+type csvResultWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (writer *csvResultWriter) WriteResult(r *Result) error {
+	if !writer.headerWritten {
+		writer.headerWritten = true
+		if err := writer.w.Write(OutputCSVHeader); err != nil {
+			return err
+		}
+	}
+	// flush every row, so the output can be followed while enumerating
+	if err := writer.w.Write(NewOutputRecord(r).CSV()); err != nil {
+		return err
+	}
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+func (writer *csvResultWriter) Flush() error {
+	if !writer.headerWritten {
+		writer.headerWritten = true
+		if err := writer.w.Write(OutputCSVHeader); err != nil {
+			return err
+		}
+	}
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+// jsonResultWriter collects every record, writing them as
+//
+//	{"example.com": {"crtsh": [OutputRecord, ...], ...}, ...}
+//
+// once flushed. Records are kept in the order they were written.
+type jsonResultWriter struct {
+	w       io.Writer
+	domains map[string]map[string][]*OutputRecord
+}
+
+func (writer *jsonResultWriter) WriteResult(r *Result) error {
+	record := NewOutputRecord(r)
+	sources, ok := writer.domains[record.Domain]
+	if !ok {
+		sources = map[string][]*OutputRecord{}
+		writer.domains[record.Domain] = sources
+	}
+	sources[record.Source] = append(sources[record.Source], record)
+	return nil
+}
+
+func (writer *jsonResultWriter) Flush() error {
+	encoder := json.NewEncoder(writer.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(writer.domains)
+}
@@ -0,0 +1,379 @@
+package core
+
+import (
+	"container/list"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// outputExtensions maps the output formats to the file extension used for them.
+var outputExtensions = map[string]string{
+	OutputPlain: "txt",
+	OutputJSONL: "jsonl",
+	OutputCSV:   "csv",
+	OutputJSON:  "json",
+}
+
+// atomicFile is written to a temporary file next to its path,
+// which is only renamed into place once committed.
+type atomicFile struct {
+	*os.File
+	path string
+}
+
+func createAtomicFile(path string) (*atomicFile, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: file, path: path}, nil
+}
+
+// commit closes the temporary file and renames it into place.
+func (file *atomicFile) commit() error {
+	err := file.Close()
+	if err == nil {
+		err = file.rename()
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// rename makes the closed temporary file readable by everyone, rather than
+// only the owner like os.CreateTemp does, and renames it into place.
+func (file *atomicFile) rename() error {
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), file.path)
+}
+
+// writeFileAtomically writes the given data to path, without ever leaving a partial file behind.
+func writeFileAtomically(path string, data []byte) error {
+	file, err := createAtomicFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	return file.commit()
+}
+
+// resultFile is a ResultWriter for a single file.
+type resultFile struct {
+	ResultWriter
+	file      *atomicFile
+	committed bool
+}
+
+// Flush flushes the underlying ResultWriter and moves the file into place.
+func (writer *resultFile) Flush() error {
+	if writer.committed {
+		return nil
+	}
+	writer.committed = true
+	if err := writer.ResultWriter.Flush(); err != nil {
+		writer.file.Close()
+		os.Remove(writer.file.Name())
+		return err
+	}
+	return writer.file.commit()
+}
+
+// WriteDiffEntry writes the given entry with the underlying ResultWriter.
+func (writer *resultFile) WriteDiffEntry(entry *DiffEntry) error {
+	return writeDiffEntry(writer.ResultWriter, entry)
+}
+
+// CreateResultFile creates a ResultWriter which writes to the file at the given path
+// in the given output format. The file only appears at the path once flushed, so a
+// crash never leaves a partial file behind.
+func CreateResultFile(path string, format string, labels bool) (ResultWriter, error) {
+	file, err := createAtomicFile(path)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := NewResultWriter(file, format, labels)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &resultFile{ResultWriter: writer, file: file}, nil
+}
+
+type multiResultWriter []ResultWriter
+
+func (writers multiResultWriter) WriteResult(r *Result) error {
+	var firstErr error
+	for _, writer := range writers {
+		if err := writer.WriteResult(r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (writers multiResultWriter) WriteDiffEntry(entry *DiffEntry) error {
+	var firstErr error
+	for _, writer := range writers {
+		if err := writeDiffEntry(writer, entry); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (writers multiResultWriter) Flush() error {
+	var firstErr error
+	for _, writer := range writers {
+		if err := writer.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// MultiResultWriter creates a ResultWriter which writes to every given ResultWriter,
+// similar to io.MultiWriter. Every writer is written to even if one fails, with the
+// first error being returned.
+func MultiResultWriter(writers ...ResultWriter) ResultWriter {
+	return multiResultWriter(writers)
+}
+
+// ManifestCounts contains the counts of a run, for a single domain or all of them.
+type ManifestCounts struct {
+	File     string         `json:"file,omitempty"` // The file the domain's results were written to.
+	Results  int            `json:"results"`        // Number of successful results.
+	Failures int            `json:"failures"`       // Number of failed results.
+	Sources  map[string]int `json:"sources"`        // Number of results from each source.
+}
+
+func (counts *ManifestCounts) add(r *Result) {
+	if r.IsFailure() {
+		counts.Failures++
+	} else {
+		counts.Results++
+	}
+	counts.Sources[r.GetType()]++
+}
+
+// RunManifest records what an OutputDirWriter wrote.
+type RunManifest struct {
+	Start   time.Time                  `json:"start"`   // When the OutputDirWriter was created.
+	End     time.Time                  `json:"end"`     // When the OutputDirWriter was flushed.
+	Format  string                     `json:"format"`  // The output format of the files.
+	Sources []string                   `json:"sources"` // The sources used.
+	Domains map[string]*ManifestCounts `json:"domains"` // The counts for each root domain.
+	Total   *ManifestCounts            `json:"total"`   // The counts for every domain.
+}
+
+// defaultMaxOpenFiles is the default of OutputDirWriter.MaxOpenFiles.
+const defaultMaxOpenFiles = 64
+
+// OutputDirWriter is a ResultWriter which writes the Results of each root domain
+// to their own file in a directory, named after the domain with the extension of
+// the output format, like example.com.jsonl. Once flushed, every file is moved into
+// place along with a manifest.json file containing the RunManifest.
+//
+// Only the files of the domains written to most recently are kept open, the
+// others are closed until written to again, so runs over thousands of domains
+// don't run out of file descriptors.
+type OutputDirWriter struct {
+	Dir          string
+	Format       string
+	Labels       bool
+	Manifest     *RunManifest
+	MaxOpenFiles int // Number of files kept open at once, defaults to 64.
+	files        map[string]*domainFile
+	open         *list.List // The open files, most recently written to first.
+}
+
+// domainFile is the file of a root domain of an OutputDirWriter, which its
+// ResultWriter writes to, reopening the file if it was closed in the meantime.
+type domainFile struct {
+	owner   *OutputDirWriter
+	file    *atomicFile
+	writer  ResultWriter
+	element *list.Element // In the open list of the owner, nil while closed.
+}
+
+// Write writes to the file, reopening it first if needed.
+func (f *domainFile) Write(p []byte) (int, error) {
+	if f.element == nil {
+		handle, err := os.OpenFile(f.file.Name(), os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return 0, err
+		}
+		f.file.File = handle
+		f.owner.track(f)
+	} else {
+		f.owner.open.MoveToFront(f.element)
+	}
+	return f.file.Write(p)
+}
+
+// close closes the file until it's written to again.
+func (f *domainFile) close() error {
+	if f.element == nil {
+		return nil
+	}
+	f.owner.open.Remove(f.element)
+	f.element = nil
+	return f.file.Close()
+}
+
+// commit flushes the ResultWriter, closes the file and moves it into place.
+func (f *domainFile) commit() error {
+	err := f.writer.Flush()
+	if closeErr := f.close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = f.file.rename()
+	}
+	if err != nil {
+		os.Remove(f.file.Name())
+	}
+	return err
+}
+
+// track records the given file as open, closing the files written to least
+// recently beyond MaxOpenFiles.
+func (writer *OutputDirWriter) track(f *domainFile) {
+	max := writer.MaxOpenFiles
+	if max <= 0 {
+		max = defaultMaxOpenFiles
+	}
+	for writer.open.Len() >= max {
+		writer.open.Back().Value.(*domainFile).close()
+	}
+	f.element = writer.open.PushFront(f)
+}
+
+// NewOutputDirWriter creates an OutputDirWriter for the given directory, which is created
+// if it doesn't exist yet. The given sources are recorded in the manifest.
+func NewOutputDirWriter(dir string, format string, labels bool, sources []Source) (*OutputDirWriter, error) {
+	// fail early on an unknown format, rather than on the first result
+	if _, err := NewResultWriter(nil, format, labels); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, source := range sources {
+		names = append(names, SourceName(source))
+	}
+
+	return &OutputDirWriter{
+		Dir:    dir,
+		Format: format,
+		Labels: labels,
+		Manifest: &RunManifest{
+			Start:   time.Now().UTC(),
+			Format:  format,
+			Sources: names,
+			Domains: map[string]*ManifestCounts{},
+			Total:   &ManifestCounts{Sources: map[string]int{}},
+		},
+		files: map[string]*domainFile{},
+		open:  list.New(),
+	}, nil
+}
+
+// domainFile returns the file of the given root domain, creating it on first use.
+func (writer *OutputDirWriter) domainFile(domain string) (*domainFile, error) {
+	if file, ok := writer.files[domain]; ok {
+		return file, nil
+	}
+
+	name := strings.Replace(domain, string(os.PathSeparator), "_", -1) + "." + outputExtensions[NormalizeOutputFormat(writer.Format)]
+	created, err := createAtomicFile(filepath.Join(writer.Dir, name))
+	if err != nil {
+		return nil, err
+	}
+	file := &domainFile{owner: writer, file: created}
+	file.writer, _ = NewResultWriter(file, writer.Format, writer.Labels)
+	writer.track(file)
+	writer.files[domain] = file
+	writer.Manifest.Domains[domain] = &ManifestCounts{File: name, Sources: map[string]int{}}
+	return file, nil
+}
+
+// WriteResult writes the given Result to the file of its root domain.
+func (writer *OutputDirWriter) WriteResult(r *Result) error {
+	domain := strings.ToLower(strings.TrimSuffix(r.GetDomain(), "."))
+	if domain == "" {
+		domain = "unknown"
+	}
+
+	file, err := writer.domainFile(domain)
+	if err != nil {
+		return err
+	}
+
+	writer.Manifest.Domains[domain].add(r)
+	writer.Manifest.Total.add(r)
+
+	return file.writer.WriteResult(r)
+}
+
+// WriteDiffEntry writes the given entry to the file of the root domain the
+// name is under: the longest of those written to, or else its registered domain.
+func (writer *OutputDirWriter) WriteDiffEntry(entry *DiffEntry) error {
+	name := NormalizeName(entry.Name)
+	domain := ""
+	for written := range writer.files {
+		if (name == written || strings.HasSuffix(name, "."+written)) && len(written) > len(domain) {
+			domain = written
+		}
+	}
+	if domain == "" {
+		domain, _ = publicsuffix.EffectiveTLDPlusOne(name)
+	}
+	if domain == "" {
+		domain = "unknown"
+	}
+
+	file, err := writer.domainFile(domain)
+	if err != nil {
+		return err
+	}
+	return writeDiffEntry(file.writer, entry)
+}
+
+// Flush moves every written file into place, followed by the manifest.
+func (writer *OutputDirWriter) Flush() error {
+	var firstErr error
+	for domain, file := range writer.files {
+		if err := file.commit(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(writer.files, domain)
+	}
+
+	writer.Manifest.End = time.Now().UTC()
+
+	data, err := json.MarshalIndent(writer.Manifest, "", "  ")
+	if err == nil {
+		err = writeFileAtomically(filepath.Join(writer.Dir, "manifest.json"), append(data, '\n'))
+	}
+	if err != nil && firstErr == nil {
+		firstErr = err
+	}
+
+	return firstErr
+}
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateResultFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.txt")
+
+	writer, err := CreateResultFile(path, OutputPlain, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.WriteResult(&Result{Type: "example", Success: "a.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing shows up at the path until flushed
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected no file before flushing, got '%v'", err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a.example.com\n" {
+		t.Fatalf("expected 'a.example.com', got '%v'", string(data))
+	}
+
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Fatalf("expected only the results file to be left, got %d files", len(entries))
+	}
+}
+
+func TestOutputDirWriter(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+
+	writer, err := NewOutputDirWriter(dir, OutputJSONL, false, []Source{&FakeSource1{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, result := range newOutputTestResults() {
+		if err := writer.WriteResult(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "example.com.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 {
+		t.Fatalf("expected 3 results for example.com, got %d", len(lines))
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "example.net.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err = ioutil.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := &RunManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.Total.Results != 3 || manifest.Total.Failures != 1 {
+		t.Fatalf("expected 3 results and 1 failure, got %d and %d", manifest.Total.Results, manifest.Total.Failures)
+	}
+	if counts := manifest.Domains["example.com"]; counts == nil || counts.Sources["crtsh"] != 2 || counts.File != "example.com.jsonl" {
+		t.Fatalf("expected counts for example.com, got '%v'", counts)
+	}
+	if len(manifest.Sources) != 1 || manifest.Sources[0] != "FakeSource1" {
+		t.Fatalf("expected '[FakeSource1]', got '%v'", manifest.Sources)
+	}
+	if manifest.End.Before(manifest.Start) {
+		t.Fatalf("expected the end '%v' after the start '%v'", manifest.End, manifest.Start)
+	}
+}
+
+func TestNewOutputDirWriter_UnknownFormat(t *testing.T) {
+	if _, err := NewOutputDirWriter(t.TempDir(), "xml", false, nil); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
+
+func TestOutputDirWriter_MaxOpenFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	writer, err := NewOutputDirWriter(dir, OutputCSV, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer.MaxOpenFiles = 2
+
+	domains := []string{"a.com", "b.com", "c.com", "d.com"}
+	for round := 0; round < 2; round++ {
+		for _, domain := range domains {
+			result := NewResult("crtsh", "www."+domain, nil)
+			result.SetDomain(domain)
+			if err := writer.WriteResult(result); err != nil {
+				t.Fatal(err)
+			}
+			if writer.open.Len() > 2 {
+				t.Fatalf("expected at most 2 open files, got %d", writer.open.Len())
+			}
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, domain := range domains {
+		path := filepath.Join(dir, domain+".csv")
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// written to across reopening, with a single header
+		if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 3 || !strings.HasPrefix(lines[0], "timestamp") {
+			t.Fatalf("expected a header and 2 results for %v, got:\n%s", domain, data)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+			t.Fatalf("expected %v to be readable by everyone, got %v", path, info.Mode())
+		}
+	}
+}
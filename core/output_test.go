@@ -0,0 +1,111 @@
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newOutputTestResults() []*Result {
+	timestamp := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	results := []*Result{
+		{Timestamp: timestamp, Type: "crtsh", Success: "www.example.com", Domain: "example.com"},
+		{Timestamp: timestamp, Type: "crtsh", Success: RelatedDomain("example.org"), Domain: "example.com"},
+		{Timestamp: timestamp, Type: "virustotal", Failure: errors.New("403 Forbidden"), Domain: "example.com"},
+		{Timestamp: timestamp, Type: "crtsh", Success: "api.example.net", Domain: "example.net"},
+	}
+	results[0].Probes = []*Probe{{FinalURL: "https://www.example.com/", StatusCode: 200}}
+	return results
+}
+
+func writeOutputTestResults(t *testing.T, format string, labels bool) string {
+	buffer := &bytes.Buffer{}
+	writer, err := NewResultWriter(buffer, format, labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range newOutputTestResults() {
+		if err := writer.WriteResult(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.String()
+}
+
+func TestNewResultWriter_Plain(t *testing.T) {
+	exp := "www.example.com [https://www.example.com/ 200 \"\"]\nrelated example.org\n403 Forbidden\napi.example.net\n"
+	if got := writeOutputTestResults(t, OutputPlain, false); got != exp {
+		t.Fatalf("expected '%v', got '%v'", exp, got)
+	}
+
+	got := writeOutputTestResults(t, OutputPlain, true)
+	if !strings.HasPrefix(got, "crtsh www.example.com") || !strings.Contains(got, "virustotal 403 Forbidden") {
+		t.Fatalf("expected labels, got '%v'", got)
+	}
+}
+
+func TestNewResultWriter_JSONL(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(writeOutputTestResults(t, OutputJSONL, false)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 lines, got %d", len(lines))
+	}
+
+	exp := `{"timestamp":"2020-01-02T03:04:05Z","domain":"example.com","source":"virustotal","error":"403 Forbidden"}`
+	if lines[2] != exp {
+		t.Fatalf("expected '%v', got '%v'", exp, lines[2])
+	}
+
+	record := &OutputRecord{}
+	if err := json.Unmarshal([]byte(lines[1]), record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Subdomain != "example.org" || !record.Related {
+		t.Fatalf("expected a related domain, got '%v'", lines[1])
+	}
+}
+
+func TestNewResultWriter_CSV(t *testing.T) {
+	rows, err := csv.NewReader(strings.NewReader(writeOutputTestResults(t, OutputCSV, false))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("expected 5 rows, got %d", len(rows))
+	}
+	if strings.Join(rows[0], ",") != strings.Join(OutputCSVHeader, ",") {
+		t.Fatalf("expected header, got '%v'", rows[0])
+	}
+	exp := "2020-01-02T03:04:05Z,example.com,crtsh,www.example.com,false,,,https://www.example.com/"
+	if strings.Join(rows[1], ",") != exp {
+		t.Fatalf("expected '%v', got '%v'", exp, strings.Join(rows[1], ","))
+	}
+}
+
+func TestNewResultWriter_JSON(t *testing.T) {
+	domains := map[string]map[string][]*OutputRecord{}
+	if err := json.Unmarshal([]byte(writeOutputTestResults(t, OutputJSON, false)), &domains); err != nil {
+		t.Fatal(err)
+	}
+	if len(domains) != 2 {
+		t.Fatalf("expected 2 domains, got %d", len(domains))
+	}
+	if len(domains["example.com"]["crtsh"]) != 2 || len(domains["example.com"]["virustotal"]) != 1 {
+		t.Fatalf("expected results grouped by source, got '%v'", domains["example.com"])
+	}
+	if domains["example.net"]["crtsh"][0].Subdomain != "api.example.net" {
+		t.Fatalf("expected 'api.example.net', got '%v'", domains["example.net"]["crtsh"][0].Subdomain)
+	}
+}
+
+func TestNewResultWriter_Unknown(t *testing.T) {
+	if _, err := NewResultWriter(&bytes.Buffer{}, "xml", false); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
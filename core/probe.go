@@ -0,0 +1,194 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// maxProbeBodySize limits how much of an HTTP response body is read while probing.
+const maxProbeBodySize = 1 << 20
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Probe contains the information gathered while connecting to a
+// found subdomain over HTTP or HTTPS.
+type Probe struct {
+	URL           string   `json:"url"`                     // The URL that was probed.
+	Port          int      `json:"port"`                    // The port that was probed.
+	StatusCode    int      `json:"status_code"`             // Status code of the final response.
+	Title         string   `json:"title,omitempty"`         // HTML title of the final response.
+	ContentLength int64    `json:"content_length"`          // Length of the final response body.
+	Server        string   `json:"server,omitempty"`        // Server header of the final response.
+	TLSSubject    string   `json:"tls_subject,omitempty"`   // Subject of the TLS certificate presented.
+	TLSDNSNames   []string `json:"tls_dns_names,omitempty"` // SANs of the TLS certificate presented.
+	FinalURL      string   `json:"final_url"`               // The URL after following redirects.
+}
+
+// String returns a human readable summary of the Probe.
+func (p *Probe) String() string {
+	return fmt.Sprintf("[%v %d %q]", p.FinalURL, p.StatusCode, p.Title)
+}
+
+// Prober connects to found subdomains over HTTP and HTTPS, recording
+// information about what is being served.
+type Prober struct {
+	Ports        []int         // Ports to probe, defaults to 80 and 443.
+	Concurrency  int           // Number of names probed at once, defaults to 10.
+	Timeout      time.Duration // Timeout for each probe, defaults to 10 seconds.
+	MaxRedirects int           // Number of redirects to follow, defaults to 10, negative values disable them.
+	HTTPClient   *http.Client  // Defaults to a client built from the options above.
+	lock         *semaphore.Weighted
+	once         sync.Once
+}
+
+func (p *Prober) init() {
+	p.once.Do(func() {
+		if len(p.Ports) == 0 {
+			p.Ports = []int{80, 443}
+		}
+		if p.Concurrency <= 0 {
+			p.Concurrency = 10
+		}
+		if p.Timeout <= 0 {
+			p.Timeout = 10 * time.Second
+		}
+		if p.MaxRedirects == 0 {
+			p.MaxRedirects = 10
+		}
+		if p.HTTPClient == nil {
+			maxRedirects := p.MaxRedirects
+			p.HTTPClient = &http.Client{
+				Timeout: p.Timeout,
+				CheckRedirect: func(req *http.Request, via []*http.Request) error {
+					if len(via) > maxRedirects {
+						return http.ErrUseLastResponse
+					}
+					return nil
+				},
+				Transport: ApplyHeaders(&http.Transport{
+					Proxy: Proxy,
+					DialContext: (&net.Dialer{
+						Timeout: p.Timeout,
+					}).DialContext,
+					// the certificate is recorded, not trusted
+					TLSClientConfig:     &tls.Config{InsecureSkipVerify: true},
+					TLSHandshakeTimeout: p.Timeout,
+					MaxIdleConnsPerHost: 1,
+					DisableKeepAlives:   true,
+				}),
+			}
+		}
+		p.lock = semaphore.NewWeighted(int64(p.Concurrency))
+	})
+}
+
+// probeURL makes a single request to the given URL, following redirects.
+func (p *Prober) probeURL(ctx context.Context, url string, port int) (*Probe, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := p.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProbeBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	probe := &Probe{
+		URL:           url,
+		Port:          port,
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		Server:        resp.Header.Get("Server"),
+		FinalURL:      resp.Request.URL.String(),
+	}
+
+	if probe.ContentLength < 0 {
+		probe.ContentLength = int64(len(body))
+	}
+
+	if match := titleRegexp.FindSubmatch(body); match != nil {
+		probe.Title = strings.TrimSpace(html.UnescapeString(string(match[1])))
+	}
+
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		probe.TLSSubject = cert.Subject.String()
+		probe.TLSDNSNames = cert.DNSNames
+	}
+
+	return probe, nil
+}
+
+// Probe connects to the given name on each of the configured ports, trying HTTPS
+// first and falling back to HTTP. An error is only returned if no port answered.
+func (p *Prober) Probe(ctx context.Context, name string) ([]*Probe, error) {
+	p.init()
+
+	probes := []*Probe{}
+	var lastErr error
+
+	for _, port := range p.Ports {
+		for _, scheme := range []string{"https", "http"} {
+			if ctx.Err() != nil {
+				return probes, ctx.Err()
+			}
+			host := net.JoinHostPort(name, strconv.Itoa(port))
+			// leave out the port if it's the default for the scheme
+			if (scheme == "https" && port == 443) || (scheme == "http" && port == 80) {
+				host = name
+			}
+			probe, err := p.probeURL(ctx, scheme+"://"+host+"/", port)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			probes = append(probes, probe)
+			break
+		}
+	}
+
+	if len(probes) == 0 {
+		if lastErr == nil {
+			lastErr = errors.New("no ports to probe")
+		}
+		return nil, lastErr
+	}
+
+	return probes, nil
+}
+
+// ProbeResults probes every successful Result from the given input channel,
+// attaching the probes to the Result before sending it down the returned
+// output channel. Failed results are passed along untouched.
+func (p *Prober) ProbeResults(ctx context.Context, input <-chan *Result) <-chan *Result {
+	p.init()
+
+	return annotateResults(ctx, input, p.lock, func(ctx context.Context, result *Result, name string) {
+		probes, err := p.Probe(ctx, name)
+		if err == nil {
+			result.SetProbes(probes)
+		}
+	})
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testServerPort(t *testing.T, server *httptest.Server) int {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProber_Probe(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		default:
+			w.Header().Set("Server", "example-server")
+			fmt.Fprint(w, "<html><head><title> Login &amp; More </title></head></html>")
+		}
+	}))
+	defer plain.Close()
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<title>Secure</title>")
+	}))
+	defer secure.Close()
+
+	plainPort := testServerPort(t, plain)
+	securePort := testServerPort(t, secure)
+
+	prober := &Prober{
+		Ports:   []int{plainPort, securePort},
+		Timeout: 5 * time.Second,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	probes, err := prober.Probe(ctx, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(probes) != 2 {
+		t.Fatalf("expected '2' probes, got '%v'", len(probes))
+	}
+
+	var units = []struct {
+		got interface{}
+		exp interface{}
+	}{
+		{probes[0].Port, plainPort},
+		{probes[0].StatusCode, 200},
+		{probes[0].Title, "Login & More"},
+		{probes[0].Server, "example-server"},
+		{probes[0].FinalURL, plain.URL + "/login"},
+		{probes[0].TLSSubject, ""},
+		{probes[1].Port, securePort},
+		{probes[1].Title, "Secure"},
+		{probes[1].ContentLength, int64(len("<title>Secure</title>"))},
+		{probes[1].FinalURL, secure.URL + "/"},
+		{probes[1].TLSDNSNames[0], "example.com"},
+	}
+	for _, u := range units {
+		if u.got != u.exp {
+			t.Fatalf("expected '%v', got '%v'", u.exp, u.got)
+		}
+	}
+}
+
+func TestProber_ProbeRedirectLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/again", http.StatusFound)
+	}))
+	defer server.Close()
+
+	prober := &Prober{
+		Ports:        []int{testServerPort(t, server)},
+		MaxRedirects: -1,
+	}
+
+	probes, err := prober.Probe(context.Background(), "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if probes[0].StatusCode != http.StatusFound {
+		t.Fatalf("expected '%v', got '%v'", http.StatusFound, probes[0].StatusCode)
+	}
+}
+
+func TestProber_ProbeResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/home" {
+			http.Redirect(w, r, "/home", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusTeapot)
+		fmt.Fprint(w, "<html><head><title>Home</title></head></html>")
+	}))
+	defer server.Close()
+
+	port := testServerPort(t, server)
+	prober := &Prober{Ports: []int{port}}
+
+	input := make(chan *Result)
+	go func() {
+		defer close(input)
+		input <- NewResult("example", "127.0.0.1", nil)
+		input <- NewResult("example", nil, fmt.Errorf("failed"))
+	}()
+
+	counter := 0
+
+	for result := range prober.ProbeResults(context.Background(), input) {
+		counter++
+		if result.IsSuccess() {
+			if len(result.GetProbes()) != 1 {
+				t.Fatalf("expected '1' probe, got '%v'", len(result.GetProbes()))
+			}
+			data, err := result.JSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := struct{ Probes []*Probe }{}
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if len(decoded.Probes) != 1 {
+				t.Fatalf("expected '1' probe in the JSON, got '%s'", data)
+			}
+			probe := decoded.Probes[0]
+			finalURL := "http://127.0.0.1:" + strconv.Itoa(port) + "/home"
+			if probe.StatusCode != http.StatusTeapot || probe.Title != "Home" || probe.FinalURL != finalURL || probe.Port != port {
+				t.Fatalf("expected a '418' probe of '%v' titled 'Home', got '%s'", finalURL, data)
+			}
+		}
+	}
+
+	if counter != 2 {
+		t.Fatalf("expected '2' results, got '%v'", counter)
+	}
+}
+
+func TestProber_ProbeResults_Canceled(t *testing.T) {
+	started := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	prober := &Prober{Ports: []int{testServerPort(t, server)}, Concurrency: 1, Timeout: time.Minute}
+
+	input := make(chan *Result)
+	go func() {
+		defer close(input)
+		for i := 0; i < 5; i++ {
+			input <- NewResult("example", "127.0.0.1", nil)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	output := prober.ProbeResults(ctx, input)
+
+	// canceled while the first probe hangs, every result still comes out right away
+	<-started
+	cancel()
+
+	done := make(chan int)
+	go func() {
+		counter := 0
+		for range output {
+			counter++
+		}
+		done <- counter
+	}()
+
+	select {
+	case counter := <-done:
+		if counter != 5 {
+			t.Fatalf("expected '5' results, got '%v'", counter)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the probes to stop once canceled")
+	}
+}
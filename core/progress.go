@@ -0,0 +1,159 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Progress reports how an enumeration is going while it runs, with the state
+// and number of results of each source, the domains remaining and the elapsed
+// time. On a terminal the report is redrawn in place, otherwise a line is
+// logged every Interval.
+type Progress struct {
+	Writer   io.Writer                      // Where to report to, like os.Stderr.
+	Terminal bool                           // Redraw the report in place, instead of logging lines.
+	Interval time.Duration                  // Between reports, defaults to a second on a terminal and 10 seconds otherwise.
+	Stats    func() *Stats                  // Returns the statistics of the sources, like EnumerationOptions.Stats.
+	Domains  func() (finished, started int) // Returns the numbers of domains enumerated so far, if set.
+	started  time.Time
+	drawn    int    // Lines drawn on the terminal, cleared before drawing again.
+	report   string // The last report drawn on the terminal.
+	mutex    sync.Mutex
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// Start reports the progress in the background, until stopped.
+func (p *Progress) Start() {
+	p.started = time.Now()
+	p.stop = make(chan struct{})
+	p.stopped = make(chan struct{})
+
+	interval := p.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+		if p.Terminal {
+			interval = time.Second
+		}
+	}
+
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.update()
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops reporting, after a final report.
+func (p *Progress) Stop() {
+	close(p.stop)
+	<-p.stopped
+	p.update()
+}
+
+// Suspend clears the report from the terminal while the given function
+// writes to it, drawing the report again after, so the two don't mix.
+func (p *Progress) Suspend(write func()) {
+	if !p.Terminal {
+		write()
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.clear()
+	write()
+	p.draw(p.report)
+}
+
+// Above returns a writer for other output to the same terminal, like logs,
+// writing each Write to w with Suspend so it shows above the report.
+func (p *Progress) Above(w io.Writer) io.Writer {
+	return &progressWriter{progress: p, writer: w}
+}
+
+// progressWriter writes above the report of a Progress.
+type progressWriter struct {
+	progress *Progress
+	writer   io.Writer
+}
+
+// Write writes the given bytes above the report.
+func (w *progressWriter) Write(b []byte) (n int, err error) {
+	w.progress.Suspend(func() {
+		n, err = w.writer.Write(b)
+	})
+	return n, err
+}
+
+// update reports the current progress.
+func (p *Progress) update() {
+	stats := p.Stats()
+	elapsed := time.Since(p.started).Round(time.Second)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if !p.Terminal {
+		fmt.Fprintln(p.Writer, p.logLine(stats, elapsed))
+		return
+	}
+
+	report := &bytes.Buffer{}
+	fmt.Fprintln(report, p.summary(stats, elapsed))
+	table := tabwriter.NewWriter(report, 0, 0, 2, ' ', 0)
+	for _, source := range stats.Sources {
+		fmt.Fprintf(table, "  %v\t%v\t%d results\t%v\n", source.Source, source.State, source.Results, formatErrors(source.Errors)+" errors")
+	}
+	table.Flush()
+
+	p.clear()
+	p.report = report.String()
+	p.draw(p.report)
+}
+
+// summary describes the elapsed time, domains and names found.
+func (p *Progress) summary(stats *Stats, elapsed time.Duration) string {
+	summary := fmt.Sprintf("%v elapsed", elapsed)
+	if p.Domains != nil {
+		finished, started := p.Domains()
+		summary += fmt.Sprintf(", %d of %d domains done, %d remaining", finished, started, started-finished)
+	}
+	return summary + fmt.Sprintf(", %d names found", stats.Names)
+}
+
+// logLine describes the progress on a single line.
+func (p *Progress) logLine(stats *Stats, elapsed time.Duration) string {
+	sources := []string{}
+	for _, source := range stats.Sources {
+		sources = append(sources, fmt.Sprintf("%v %v %d", source.Source, source.State, source.Results))
+	}
+	return "progress: " + p.summary(stats, elapsed) + "; " + strings.Join(sources, ", ")
+}
+
+// draw writes the report to the terminal.
+func (p *Progress) draw(report string) {
+	io.WriteString(p.Writer, report)
+	p.drawn = strings.Count(report, "\n")
+}
+
+// clear moves the cursor up to the start of the drawn report, and clears it.
+func (p *Progress) clear() {
+	if p.drawn > 0 {
+		fmt.Fprintf(p.Writer, "\033[%dA\033[J", p.drawn)
+		p.drawn = 0
+	}
+}
@@ -0,0 +1,82 @@
+package core
+
+import (
+	"bytes"
+	"log/slog"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testProgressStats() *Stats {
+	return &Stats{Names: 3, Sources: []*SourceStats{
+		{Source: "CrtSh", State: SourceRunning, Results: 3, Errors: map[string]int{}},
+		{Source: "Bing", State: SourceFailed, Errors: map[string]int{ErrorTimeout: 1}},
+		{Source: "Ask", State: SourceQueued, Errors: map[string]int{}},
+	}}
+}
+
+func TestProgress_Log(t *testing.T) {
+	output := &bytes.Buffer{}
+	progress := &Progress{
+		Writer:   output,
+		Interval: 10 * time.Millisecond,
+		Stats:    testProgressStats,
+		Domains:  func() (int, int) { return 1, 4 },
+	}
+	progress.Start()
+	time.Sleep(25 * time.Millisecond)
+	progress.Stop()
+
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected a line for every interval and when stopped, got '%v'", output)
+	}
+	expected := "progress: 0s elapsed, 1 of 4 domains done, 3 remaining, 3 names found; CrtSh running 3, Bing failed 0, Ask queued 0"
+	if lines[len(lines)-1] != expected {
+		t.Fatalf("expected '%v', got '%v'", expected, lines[len(lines)-1])
+	}
+	if strings.Contains(output.String(), "\033[") {
+		t.Fatal("expected no escape codes when not on a terminal")
+	}
+}
+
+func TestProgress_Terminal(t *testing.T) {
+	output := &bytes.Buffer{}
+	progress := &Progress{Writer: output, Terminal: true, Interval: time.Hour, Stats: testProgressStats}
+	progress.Start()
+	progress.Stop()
+
+	report := output.String()
+	if !strings.HasPrefix(report, "0s elapsed, 3 names found\n") || strings.Count(report, "\n") != 4 || !strings.Contains(report, "Bing   failed   0 results  1 (timeout 1) errors") {
+		t.Fatalf("unexpected report:\n%v", report)
+	}
+
+	// the report is cleared and drawn again around other output
+	output.Reset()
+	progress.Suspend(func() {
+		output.WriteString("www.example.com\n")
+	})
+	if !strings.HasPrefix(output.String(), "\033[4A\033[Jwww.example.com\n0s elapsed") {
+		t.Fatalf("expected the report to be redrawn below the output, got %q", output)
+	}
+}
+
+func TestProgress_Above(t *testing.T) {
+	output := &bytes.Buffer{}
+	progress := &Progress{Writer: output, Terminal: true, Interval: time.Hour, Stats: testProgressStats}
+	progress.Start()
+	progress.Stop()
+
+	// logs to the same terminal don't clear the report, nor get cleared by it
+	output.Reset()
+	logger, err := NewLogger(progress.Above(output), LogText, slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info("source failed", "source", "Bing")
+	if !regexp.MustCompile(`^\033\[4A\033\[Jtime=\S+ level=INFO msg="source failed" source=Bing\n0s elapsed`).MatchString(output.String()) {
+		t.Fatalf("expected the log to be written above the report, got %q", output)
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"context"
+)
+
+// sourceRecords stores what a source found for each domain, for a recordedSource.
+type sourceRecords interface {
+	// load returns what the source found for the domain, if it's stored.
+	load(domain string) ([]*OutputRecord, bool)
+	// store keeps what the source found for the domain, once it finished it without failures.
+	store(domain string, records []*OutputRecord) error
+}
+
+// recordedSource is a Source which replays what it found for a domain from its
+// sourceRecords, or queries the wrapped Source and stores what it finds. Only
+// complete runs without any failures are stored, so the others are retried.
+type recordedSource struct {
+	Source
+	records sourceRecords
+	name    string
+}
+
+// Unwrap returns the Source being recorded.
+func (s *recordedSource) Unwrap() Source {
+	return s.Source
+}
+
+// ProcessDomain replays the stored results for the domain, or queries the source and stores what it finds.
+func (s *recordedSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+
+	go func() {
+		defer close(results)
+
+		if records, ok := s.records.load(domain); ok {
+			for _, record := range records {
+				select {
+				case results <- record.Result():
+				case <-ctx.Done():
+					return
+				}
+			}
+			return
+		}
+
+		records := []*OutputRecord{}
+		complete := true
+
+		for result := range s.Source.ProcessDomain(ctx, domain) {
+			if result.IsFailure() {
+				complete = false
+			} else {
+				records = append(records, NewOutputRecord(result))
+			}
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				complete = false
+			}
+		}
+
+		if complete && ctx.Err() == nil {
+			if err := s.records.store(domain, records); err != nil {
+				select {
+				case results <- NewResult(s.name, nil, err):
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+
+	return results
+}
+
+// wrapAll returns every given Source wrapped with the given function.
+func wrapAll(sources []Source, wrap func(Source) Source) []Source {
+	wrapped := []Source{}
+	for _, source := range sources {
+		wrapped = append(wrapped, wrap(source))
+	}
+	return wrapped
+}
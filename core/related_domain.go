@@ -0,0 +1,15 @@
+package core
+
+// RelatedDomain is used as the Success value of a Result when a source finds
+// a name that belongs to another apex domain than the one being enumerated,
+// like a sibling brand found in a certificate. It is a distinct type so it isn't
+// mistaken for a found subdomain by UniqResults or recursive enumeration.
+type RelatedDomain string
+
+// IsRelatedDomain checks if the Result's Success value is a RelatedDomain.
+func (r *Result) IsRelatedDomain() bool {
+	r.RLock()
+	defer r.RUnlock()
+	_, ok := r.Success.(RelatedDomain)
+	return ok
+}
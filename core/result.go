@@ -16,21 +16,26 @@ import (
 // the source should provide an error.
 type Result struct {
 	sync.RWMutex
-	Timestamp time.Time
-	Type      string
-	Success   interface{}
-	Failure   error
+	Timestamp   time.Time
+	Type        string
+	Success     interface{}
+	Failure     error
+	Domain      string       `json:",omitempty"` // The domain being enumerated when the Result was found.
+	Takeover    *Takeover    `json:",omitempty"`
+	Probes      []*Probe     `json:",omitempty"`
+	Certificate *Certificate `json:",omitempty"`
 }
 
 // NewResult wraps up the creation of a new Result. This function
 // will set the Timestamp value of the Result to the current time
-// in UTC format, which is always preferred.
+// in UTC format, which is always preferred. Secrets in the error,
+// like the API keys in the URLs of HTTP errors, are redacted.
 func NewResult(t string, s interface{}, f error) *Result {
 	return &Result{
 		Type:      t,
 		Timestamp: time.Now().UTC(),
 		Success:   s,
-		Failure:   f,
+		Failure:   RedactError(f),
 	}
 }
 
@@ -51,11 +56,12 @@ func (r *Result) GetSuccess() interface{} {
 }
 
 // SetFailure safely sets a new error value for a Result
-// which could be shared by multiple go routines.
+// which could be shared by multiple go routines, redacting
+// any secrets like NewResult.
 func (r *Result) SetFailure(err error) {
 	r.Lock()
 	defer r.Unlock()
-	r.Failure = err
+	r.Failure = RedactError(err)
 }
 
 // GetFailure safely gets the error value from a Result
@@ -98,6 +104,70 @@ func (r *Result) GetTimestamp() time.Time {
 	return r.Timestamp
 }
 
+// SetDomain safely sets the enumerated domain for a Result
+// which could be shared by multiple go routines.
+func (r *Result) SetDomain(domain string) {
+	r.Lock()
+	defer r.Unlock()
+	r.Domain = domain
+}
+
+// GetDomain safely gets the enumerated domain from a Result
+// which could be shared by multiple go routines.
+func (r *Result) GetDomain() string {
+	r.RLock()
+	defer r.RUnlock()
+	return r.Domain
+}
+
+// SetTakeover safely sets a new Takeover value for a Result
+// which could be shared by multiple go routines.
+func (r *Result) SetTakeover(t *Takeover) {
+	r.Lock()
+	defer r.Unlock()
+	r.Takeover = t
+}
+
+// GetTakeover safely gets the Takeover value from a Result
+// which could be shared by multiple go routines.
+func (r *Result) GetTakeover() *Takeover {
+	r.RLock()
+	defer r.RUnlock()
+	return r.Takeover
+}
+
+// SetProbes safely sets new Probes for a Result
+// which could be shared by multiple go routines.
+func (r *Result) SetProbes(p []*Probe) {
+	r.Lock()
+	defer r.Unlock()
+	r.Probes = p
+}
+
+// GetProbes safely gets the Probes from a Result
+// which could be shared by multiple go routines.
+func (r *Result) GetProbes() []*Probe {
+	r.RLock()
+	defer r.RUnlock()
+	return r.Probes
+}
+
+// SetCertificate safely sets new Certificate metadata for a Result
+// which could be shared by multiple go routines.
+func (r *Result) SetCertificate(c *Certificate) {
+	r.Lock()
+	defer r.Unlock()
+	r.Certificate = c
+}
+
+// GetCertificate safely gets the Certificate metadata from a Result
+// which could be shared by multiple go routines.
+func (r *Result) GetCertificate() *Certificate {
+	r.RLock()
+	defer r.RUnlock()
+	return r.Certificate
+}
+
 // IsSuccess checks if the Result has any Failure, or
 // that the Success interface{} has actually been filled
 // before determining if the result succeeded.
@@ -197,9 +267,31 @@ func (r *Result) Print() error {
 	return errors.New("unable to print unprintable result")
 }
 
-// JSON returns the Result as a JSON object within a slice of bytes.
-func (r *Result) JSON() ([]byte, error) {
+// MarshalJSON encodes the Result with its Failure as the error
+// string, since an error has no exported fields of its own.
+func (r *Result) MarshalJSON() ([]byte, error) {
 	r.RLock()
 	defer r.RUnlock()
+
+	var failure *string
+	if r.Failure != nil {
+		str := RedactString(r.Failure.Error())
+		failure = &str
+	}
+
+	return json.Marshal(struct {
+		Timestamp   time.Time
+		Type        string
+		Success     interface{}
+		Failure     *string
+		Domain      string       `json:",omitempty"`
+		Takeover    *Takeover    `json:",omitempty"`
+		Probes      []*Probe     `json:",omitempty"`
+		Certificate *Certificate `json:",omitempty"`
+	}{r.Timestamp, r.Type, r.Success, failure, r.Domain, r.Takeover, r.Probes, r.Certificate})
+}
+
+// JSON returns the Result as a JSON object within a slice of bytes.
+func (r *Result) JSON() ([]byte, error) {
 	return json.Marshal(r)
 }
@@ -158,6 +158,8 @@ func TestResult_JSON(t *testing.T) {
 		{NewResult("", "", nil), `{"Timestamp":"0001-01-01T00:00:00Z","Type":"","Success":"","Failure":null}`},
 		{NewResult("example", "", nil), `{"Timestamp":"0001-01-01T00:00:00Z","Type":"example","Success":"","Failure":null}`},
 		{NewResult("example", "a.b.com", nil), `{"Timestamp":"0001-01-01T00:00:00Z","Type":"example","Success":"a.b.com","Failure":null}`},
+		{NewResult("example", nil, errors.New("failed")), `{"Timestamp":"0001-01-01T00:00:00Z","Type":"example","Success":null,"Failure":"failed"}`},
+		{&Result{Type: "example", Success: "a.b.com", Domain: "b.com"}, `{"Timestamp":"0001-01-01T00:00:00Z","Type":"example","Success":"a.b.com","Failure":null,"Domain":"b.com"}`},
 	}
 	for _, u := range units {
 		u.got.Timestamp = time.Time{} // ensure this isn't the reason for failure
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when a Watcher runs next.
+type Schedule interface {
+	// Next returns the first time after the given time to run at.
+	Next(time.Time) time.Time
+}
+
+// everySchedule runs at a fixed interval.
+type everySchedule time.Duration
+
+func (every everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(every))
+}
+
+// cronSchedule runs at the minutes matching each of its fields.
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays map[int]bool
+	anyDay, anyWeekday                     bool
+}
+
+// parseCronField parses a single cron field, like *, 5, 1-5, */15, 0-30/10 or 1,2,3.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", field)
+			}
+			part = part[:i]
+		}
+
+		start, end := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			start, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in %q", field)
+			}
+			end = start
+			if len(bounds) == 2 {
+				end, err = strconv.Atoi(bounds[1])
+				if err != nil {
+					return nil, fmt.Errorf("invalid range in %q", field)
+				}
+			} else if step > 1 {
+				// like 5/15, from 5 up to the max
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("%q is out of range %d-%d", field, min, max)
+		}
+
+		for value := start; value <= end; value += step {
+			values[value] = true
+		}
+	}
+
+	return values, nil
+}
+
+func (cron *cronSchedule) matches(t time.Time) bool {
+	if !cron.minutes[t.Minute()] || !cron.hours[t.Hour()] || !cron.months[int(t.Month())] {
+		return false
+	}
+	day, weekday := cron.days[t.Day()], cron.weekdays[int(t.Weekday())]
+	// like cron, when both are restricted either of them matching is enough
+	if !cron.anyDay && !cron.anyWeekday {
+		return day || weekday
+	}
+	return day && weekday
+}
+
+func (cron *cronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	// five years covers every valid schedule, including February 29th
+	for limit := next.AddDate(5, 0, 0); next.Before(limit); next = next.Add(time.Minute) {
+		if cron.matches(next) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// ParseSchedule parses a cron-like schedule. This is either the standard five
+// cron fields (minute, hour, day of month, month and day of week, where Sunday
+// is 0), one of @hourly, @daily or @weekly, or "@every <duration>" like "@every 6h".
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		every, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, err
+		}
+		if every < time.Second {
+			return nil, fmt.Errorf("schedule interval %v is too short", every)
+		}
+		return everySchedule(every), nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in schedule %q, got %d", spec, len(fields))
+	}
+
+	// like cron, a field starting with a star, like */2, doesn't restrict the day
+	cron := &cronSchedule{anyDay: strings.HasPrefix(fields[2], "*"), anyWeekday: strings.HasPrefix(fields[4], "*")}
+
+	var err error
+	if cron.minutes, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if cron.hours, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if cron.days, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if cron.months, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if cron.weekdays, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	// 7 is also Sunday
+	if cron.weekdays[7] {
+		cron.weekdays[0] = true
+	}
+
+	return cron, nil
+}
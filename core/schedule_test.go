@@ -0,0 +1,42 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	from := time.Date(2020, 1, 1, 10, 30, 15, 0, time.UTC) // a Wednesday
+
+	var units = []struct {
+		spec string
+		exp  time.Time
+	}{
+		{"* * * * *", time.Date(2020, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{"5,35 9-17 * * *", time.Date(2020, 1, 1, 10, 35, 0, 0, time.UTC)},
+		{"0 3 * * 1-5", time.Date(2020, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */2 * 1", time.Date(2020, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@every 6h", from.Add(6 * time.Hour)},
+	}
+	for _, u := range units {
+		schedule, err := ParseSchedule(u.spec)
+		if err != nil {
+			t.Fatalf("%v: %v", u.spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(u.exp) {
+			t.Fatalf("%v: expected '%v', got '%v'", u.spec, u.exp, got)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "@every 1ms", "@every soon"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Fatalf("expected an error for %q", spec)
+		}
+	}
+}
@@ -1,9 +1,54 @@
 package core
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
 
 // Source defines the minimum interface any
 // subdomain enumeration module should follow.
 type Source interface {
 	ProcessDomain(context.Context, string) <-chan *Result
 }
+
+// SourceName returns the name of the given Source's type, like CrtSh. Sources
+// wrapping another one, like those of a SourceCache, have the name of the
+// wrapped Source.
+func SourceName(source Source) string {
+	if wrapper, ok := source.(interface{ Unwrap() Source }); ok {
+		return SourceName(wrapper.Unwrap())
+	}
+	t := reflect.TypeOf(source)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// SelectSources returns the sources whose SourceName matches one of the given
+// names case insensitively, in the order of the names. Every source is
+// returned when no names are given.
+func SelectSources(sources []Source, names []string) ([]Source, error) {
+	if len(names) == 0 {
+		return sources, nil
+	}
+
+	selected := []Source{}
+	for _, name := range names {
+		found := false
+		for _, source := range sources {
+			if strings.EqualFold(SourceName(source), name) {
+				selected = append(selected, source)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown source %q", name)
+		}
+	}
+
+	return selected, nil
+}
@@ -0,0 +1,40 @@
+package core
+
+import (
+	"context"
+	"testing"
+)
+
+type firstSource struct{}
+
+func (s *firstSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+	close(results)
+	return results
+}
+
+type secondSource struct{}
+
+func (s *secondSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+	close(results)
+	return results
+}
+
+func TestSelectSources(t *testing.T) {
+	sources := []Source{&firstSource{}, &secondSource{}}
+
+	selected, err := SelectSources(sources, nil)
+	if err != nil || len(selected) != 2 {
+		t.Fatalf("expected every source, got '%v' '%v'", selected, err)
+	}
+
+	selected, err = SelectSources(sources, []string{"SECONDSOURCE", "firstsource"})
+	if err != nil || len(selected) != 2 || SourceName(selected[0]) != "secondSource" || SourceName(selected[1]) != "firstSource" {
+		t.Fatalf("expected secondSource and firstSource, got '%v' '%v'", selected, err)
+	}
+
+	if _, err := SelectSources(sources, []string{"nope"}); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+}
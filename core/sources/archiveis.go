@@ -28,7 +28,7 @@ func (source *ArchiveIs) ProcessDomain(ctx context.Context, domain string) <-cha
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(archiveisLabel, nil, err))
 			return
 		}
@@ -37,6 +37,8 @@ func (source *ArchiveIs) ProcessDomain(ctx context.Context, domain string) <-cha
 		domainExtractor := core.NewSingleSubdomainExtractor(domain)
 
 		for currentPage := 0; currentPage <= 750; currentPage += 10 {
+			core.Logger(ctx).Log(ctx, core.LevelTrace, "fetching page", "offset", currentPage)
+
 			if ctx.Err() != nil {
 				return
 			}
@@ -50,7 +52,7 @@ func (source *ArchiveIs) ProcessDomain(ctx context.Context, domain string) <-cha
 			}
 
 			req.Cancel = ctx.Done()
-			req.WithContext(ctx)
+			req = req.WithContext(ctx)
 
 			resp, err := core.HTTPClient.Do(req)
 			if err != nil {
@@ -28,7 +28,7 @@ func (source *Ask) ProcessDomain(ctx context.Context, domain string) <-chan *cor
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(askLabel, nil, err))
 			return
 		}
@@ -37,6 +37,8 @@ func (source *Ask) ProcessDomain(ctx context.Context, domain string) <-chan *cor
 		domainExtractor := core.NewSingleSubdomainExtractor(domain)
 
 		for currentPage := 1; currentPage <= 750; currentPage++ {
+			core.Logger(ctx).Log(ctx, core.LevelTrace, "fetching page", "page", currentPage)
+
 			url := "https://www.ask.com/web?q=site%3A" + domain + "+-www.+&page=" + strconv.Itoa(currentPage) + "&o=0&l=dir&qsrc=998&qo=pagination"
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			if err != nil {
@@ -45,7 +47,7 @@ func (source *Ask) ProcessDomain(ctx context.Context, domain string) <-chan *cor
 			}
 
 			req.Cancel = ctx.Done()
-			req.WithContext(ctx)
+			req = req.WithContext(ctx)
 
 			resp, err := core.HTTPClient.Do(req)
 			if err != nil {
@@ -27,7 +27,7 @@ func (source *Baidu) ProcessDomain(ctx context.Context, domain string) <-chan *c
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(baiduLabel, nil, err))
 			return
 		}
@@ -36,6 +36,8 @@ func (source *Baidu) ProcessDomain(ctx context.Context, domain string) <-chan *c
 		domainExtractor := core.NewSingleSubdomainExtractor(domain)
 
 		for currentPage := 1; currentPage <= 750; currentPage++ {
+			core.Logger(ctx).Log(ctx, core.LevelTrace, "fetching page", "page", currentPage)
+
 			url := "https://www.baidu.com/s?rn=10&pn=" + strconv.Itoa(currentPage) + "&wd=site%3A" + domain + "+-www.+&oq=site%3A" + domain + "+-www.+"
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			if err != nil {
@@ -44,7 +46,7 @@ func (source *Baidu) ProcessDomain(ctx context.Context, domain string) <-chan *c
 			}
 
 			req.Cancel = ctx.Done()
-			req.WithContext(ctx)
+			req = req.WithContext(ctx)
 
 			resp, err := core.HTTPClient.Do(req)
 			if err != nil {
@@ -27,7 +27,7 @@ func (source *Bing) ProcessDomain(ctx context.Context, domain string) <-chan *co
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(bingLabel, nil, err))
 			return
 		}
@@ -36,6 +36,8 @@ func (source *Bing) ProcessDomain(ctx context.Context, domain string) <-chan *co
 		domainExtractor := core.NewSingleSubdomainExtractor(domain)
 
 		for currentPage := 1; currentPage <= 750; currentPage += 10 {
+			core.Logger(ctx).Log(ctx, core.LevelTrace, "fetching page", "offset", currentPage)
+
 			if ctx.Err() != nil {
 				return
 			}
@@ -48,7 +50,7 @@ func (source *Bing) ProcessDomain(ctx context.Context, domain string) <-chan *co
 			}
 
 			req.Cancel = ctx.Done()
-			req.WithContext(ctx)
+			req = req.WithContext(ctx)
 
 			resp, err := core.HTTPClient.Do(req)
 			if err != nil {
@@ -3,8 +3,11 @@ package sources
 import (
 	"bufio"
 	"context"
-	"encoding/base64"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"sync"
@@ -13,6 +16,63 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
+type certspotterCertObject struct {
+	ID   string `json:"id"`
+	Data []byte `json:"data"` // base64 encoded DER
+}
+
+// certspotterCertResults decodes the JSON array of certificates from the v1 certs API,
+// sending a Result for each name in scope found in the parsed certificates.
+func certspotterCertResults(ctx context.Context, body io.Reader, domain string, results chan *core.Result) {
+	domainExtractor := core.NewSingleSubdomainExtractor(domain)
+
+	decoder := json.NewDecoder(body)
+
+	// opening bracket of the array
+	if _, err := decoder.Token(); err != nil {
+		sendResultWithContext(ctx, results, core.NewResult(certspotterLabel, nil, err))
+		return
+	}
+
+	for decoder.More() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		object := &certspotterCertObject{}
+
+		err := decoder.Decode(object)
+		if err != nil {
+			core.Logger(ctx).Debug("parse failed", "offset", decoder.InputOffset(), "error", err)
+			sendResultWithContext(ctx, results, core.NewResult(certspotterLabel, nil, err))
+			return
+		}
+
+		cert, err := x509.ParseCertificate(object.Data)
+		if err != nil {
+			core.Logger(ctx).Debug("skipping certificate", "id", object.ID, "error", err)
+			if !sendResultWithContext(ctx, results, core.NewResult(certspotterLabel, nil, fmt.Errorf("certificate %v: %v", object.ID, err))) {
+				return
+			}
+			continue
+		}
+
+		metadata := core.NewCertificate(cert)
+
+		for _, name := range core.CertificateNames(cert) {
+			str := domainExtractor([]byte(strings.ToLower(name)))
+			if str == "" {
+				continue
+			}
+			result := core.NewResult(certspotterLabel, str, nil)
+			result.SetCertificate(metadata)
+			if !sendResultWithContext(ctx, results, result) {
+				return
+			}
+		}
+	}
+}
+
 // CertSpotter is a source to process subdomains from https://certspotter.com
 type CertSpotter struct {
 	APIToken string
@@ -35,7 +95,7 @@ func (source *CertSpotter) ProcessDomain(ctx context.Context, domain string) <-c
 	go func(domain string, results chan *core.Result) {
 		defer wg.Done()
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(certspotterLabel, nil, err))
 			return
 		}
@@ -53,7 +113,7 @@ func (source *CertSpotter) ProcessDomain(ctx context.Context, domain string) <-c
 		}
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
@@ -94,8 +154,6 @@ func (source *CertSpotter) ProcessDomain(ctx context.Context, domain string) <-c
 	go func(domain string, results chan *core.Result) {
 		defer wg.Done()
 
-		domainExtractor := core.NewSingleSubdomainExtractor(domain)
-
 		url := "https://api.certspotter.com/v1/certs?domain=" + domain + "&include_subdomains=true&expand=dns_names&match_wildcards=true"
 
 		req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -109,7 +167,7 @@ func (source *CertSpotter) ProcessDomain(ctx context.Context, domain string) <-c
 			req.Header.Set("Authorization", "Bearer "+source.APIToken)
 		}
 
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
@@ -123,41 +181,7 @@ func (source *CertSpotter) ProcessDomain(ctx context.Context, domain string) <-c
 			return
 		}
 
-		scanner := bufio.NewScanner(resp.Body)
-
-		for scanner.Scan() {
-			if ctx.Err() != nil {
-				return
-			}
-
-			if !strings.Contains(scanner.Text(), `"data":"`) {
-				continue
-			}
-
-			str := scanner.Text()
-			str = strings.TrimSpace(str)
-			str = strings.Trim(str, `"data":"`)
-			str = strings.Trim(str, `"`)
-
-			decodedData, err := base64.StdEncoding.DecodeString(str)
-
-			if err != nil {
-				continue
-			}
-
-			for _, str := range domainExtractor(decodedData) {
-				if !sendResultWithContext(ctx, results, core.NewResult(certspotterLabel, str, nil)) {
-					return
-				}
-			}
-		}
-
-		err = scanner.Err()
-
-		if err != nil {
-			sendResultWithContext(ctx, results, core.NewResult(certspotterLabel, nil, err))
-			return
-		}
+		certspotterCertResults(ctx, resp.Body, domain, results)
 
 	}(domain, results)
 
@@ -180,7 +204,7 @@ func (source *CertSpotter) ProcessDomain(ctx context.Context, domain string) <-c
 			req.Header.Set("Authorization", "Bearer "+source.APIToken)
 		}
 
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
@@ -1,8 +1,14 @@
 package sources
 
 import (
+	"bytes"
 	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"testing"
 	"time"
 
@@ -154,3 +160,76 @@ func TestCertSpotterRecursive(t *testing.T) {
 // 		wg.Wait() // collect results
 // 	}
 // }
+
+func TestCertSpotterCertResults(t *testing.T) {
+	valid, _ := newTestCertificate(t, &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "www.example.com"},
+		DNSNames:  []string{"www.example.com", "api.example.com", "example.org"},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	})
+	expired, _ := newTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(255),
+		Subject:      pkix.Name{CommonName: "old.example.com"},
+		NotBefore:    time.Now().Add(-2 * time.Hour),
+		NotAfter:     time.Now().Add(-time.Hour),
+	})
+	precert, _ := newTestCertificate(t, &x509.Certificate{
+		DNSNames:        []string{"new.example.com"},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}, Critical: true, Value: []byte{5, 0}}},
+	})
+
+	body, err := json.MarshalIndent([]*certspotterCertObject{
+		{ID: "1", Data: valid},
+		{ID: "2", Data: expired},
+		{ID: "3", Data: precert},
+		{ID: "4", Data: []byte("garbage")},
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := make(chan *core.Result)
+	go func() {
+		defer close(results)
+		certspotterCertResults(context.Background(), bytes.NewReader(body), "example.com", results)
+	}()
+
+	found := map[string]*core.Certificate{}
+	failures := 0
+
+	for result := range results {
+		if result.IsFailure() {
+			failures++
+			continue
+		}
+		found[result.Success.(string)] = result.GetCertificate()
+	}
+
+	if len(found) != 4 {
+		t.Fatalf("expected '4' names, got '%v'", found)
+	}
+
+	if failures != 1 {
+		t.Fatalf("expected '1' failure, got '%v'", failures)
+	}
+
+	var units = []struct {
+		got interface{}
+		exp interface{}
+	}{
+		{found["www.example.com"].Expired, false},
+		{found["api.example.com"].Precertificate, false},
+		{found["old.example.com"].Expired, true},
+		{found["old.example.com"].Serial, "ff"},
+		{found["old.example.com"].Issuer, "CN=old.example.com"},
+		{found["new.example.com"].Precertificate, true},
+	}
+	for _, u := range units {
+		if u.got != u.exp {
+			t.Fatalf("expected '%v', got '%v'", u.exp, u.got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package sources
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/subfinder/research/core"
+)
+
+// Certstream is a core.Monitor which follows a certstream compatible
+// websocket feed, like https://certstream.calidog.io, sending the names
+// from new certificates which are under any of the watched domains.
+// The feed is reconnected to with a backoff whenever it drops.
+type Certstream struct {
+	URL    string            // Defaults to wss://certstream.calidog.io/
+	Dialer *websocket.Dialer // Defaults to websocket.DefaultDialer, through core.Proxy.
+}
+
+type certstreamMessage struct {
+	MessageType string `json:"message_type"`
+	Data        struct {
+		CertIndex int64 `json:"cert_index"`
+		LeafCert  struct {
+			AllDomains   []string `json:"all_domains"`
+			NotBefore    float64  `json:"not_before"`
+			NotAfter     float64  `json:"not_after"`
+			SerialNumber string   `json:"serial_number"`
+			Issuer       struct {
+				Aggregated string `json:"aggregated"`
+			} `json:"issuer"`
+		} `json:"leaf_cert"`
+		Chain []struct {
+			Subject struct {
+				Aggregated string `json:"aggregated"`
+			} `json:"subject"`
+		} `json:"chain"`
+		Source struct {
+			URL string `json:"url"`
+		} `json:"source"`
+	} `json:"data"`
+}
+
+// certificate returns the metadata of the certificate from a certificate_update message.
+func (message *certstreamMessage) certificate() *core.Certificate {
+	leaf := message.Data.LeafCert
+
+	issuer := leaf.Issuer.Aggregated
+	if issuer == "" && len(message.Data.Chain) > 0 {
+		issuer = message.Data.Chain[0].Subject.Aggregated
+	}
+
+	notAfter := time.Unix(int64(leaf.NotAfter), 0).UTC()
+
+	return &core.Certificate{
+		ID:        strings.TrimSuffix(message.Data.Source.URL, "/") + "#" + strconv.FormatInt(message.Data.CertIndex, 10),
+		Issuer:    issuer,
+		NotBefore: time.Unix(int64(leaf.NotBefore), 0).UTC(),
+		NotAfter:  notAfter,
+		Serial:    strings.ToLower(leaf.SerialNumber),
+		Expired:   time.Now().After(notAfter),
+	}
+}
+
+// follow reads from a single connection to the feed until it drops or the context is canceled.
+func (monitor *Certstream) follow(ctx context.Context, conn *websocket.Conn, matches func(string) string, results chan *core.Result) error {
+	// unblock the read below once canceled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		message := &certstreamMessage{}
+		err := conn.ReadJSON(message)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+
+		if message.MessageType != "certificate_update" {
+			// heartbeats and anything else
+			continue
+		}
+
+		var metadata *core.Certificate
+		seen := map[string]bool{}
+
+		for _, name := range message.Data.LeafCert.AllDomains {
+			name = strings.ToLower(strings.TrimPrefix(name, "*."))
+			domain := matches(name)
+			if seen[name] || domain == "" {
+				continue
+			}
+			seen[name] = true
+			if metadata == nil {
+				metadata = message.certificate()
+			}
+			result := core.NewResult(certstreamLabel, name, nil)
+			result.SetDomain(domain)
+			result.SetCertificate(metadata)
+			if !sendResultWithContext(ctx, results, result) {
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Monitor follows the feed until the given context is canceled, sending new
+// names under the given domains down the returned results channel.
+func (monitor *Certstream) Monitor(ctx context.Context, domains []string) <-chan *core.Result {
+	if monitor.URL == "" {
+		monitor.URL = "wss://certstream.calidog.io/"
+	}
+
+	if monitor.Dialer == nil {
+		dialer := *websocket.DefaultDialer
+		dialer.Proxy = core.Proxy
+		monitor.Dialer = &dialer
+	}
+
+	results := make(chan *core.Result)
+
+	go func() {
+		defer close(results)
+
+		matches := newDomainsMatcher(domains)
+		backoff := time.Second
+
+		for ctx.Err() == nil {
+			conn, _, err := monitor.Dialer.DialContext(ctx, monitor.URL, nil)
+			if err == nil {
+				backoff = time.Second
+				err = monitor.follow(ctx, conn, matches, results)
+				conn.Close()
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+
+			if !sendResultWithContext(ctx, results, core.NewResult(certstreamLabel, nil, err)) {
+				return
+			}
+
+			core.Logger(ctx).Debug("reconnecting", "backoff", backoff, "error", err)
+			if !sleepWithContext(ctx, backoff) {
+				return
+			}
+			backoff = nextBackoff(backoff)
+		}
+	}()
+
+	return results
+}
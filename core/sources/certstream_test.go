@@ -0,0 +1,82 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestCertstream(t *testing.T) {
+	messages := []string{
+		`{"message_type":"certificate_update","data":{"cert_index":7,"leaf_cert":{"all_domains":["*.dev.example.com","example.com","www.example.org"],"not_before":1700000000,"not_after":4100000000,"serial_number":"0A","issuer":{"aggregated":"/CN=Test CA"}},"source":{"url":"https://ct.example.net/log/"}}}`,
+		`{"message_type":"heartbeat","timestamp":1700000000}`,
+		`{"message_type":"certificate_update","data":{"cert_index":8,"leaf_cert":{"all_domains":["mail.example.com"],"not_before":1700000000,"not_after":4100000000,"serial_number":"0B"},"chain":[{"subject":{"aggregated":"/CN=Other CA"}}],"source":{"url":"https://ct.example.net/log/"}}}`,
+	}
+
+	mutex := sync.Mutex{}
+	connections := 0
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		mutex.Lock()
+		connections++
+		first := connections == 1
+		mutex.Unlock()
+
+		// drop the first connection half way through to force a reconnect
+		if first {
+			conn.WriteMessage(websocket.TextMessage, []byte(messages[0]))
+			return
+		}
+		for _, message := range messages[1:] {
+			conn.WriteMessage(websocket.TextMessage, []byte(message))
+		}
+		time.Sleep(time.Second)
+	}))
+	defer server.Close()
+
+	monitor := &Certstream{URL: "ws" + strings.TrimPrefix(server.URL, "http")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	found := []string{}
+	failures := 0
+
+	for result := range monitor.Monitor(ctx, []string{"example.com"}) {
+		if result.IsFailure() {
+			failures++
+			continue
+		}
+		found = append(found, result.Success.(string))
+		if len(found) == 1 {
+			cert := result.GetCertificate()
+			if cert.ID != "https://ct.example.net/log#7" || cert.Issuer != "/CN=Test CA" || cert.Serial != "0a" || cert.Expired {
+				t.Fatalf("unexpected certificate metadata '%v'", cert)
+			}
+		}
+		if len(found) == 2 {
+			cancel()
+		}
+	}
+
+	if len(found) != 2 || found[0] != "dev.example.com" || found[1] != "mail.example.com" {
+		t.Fatalf("expected '[dev.example.com mail.example.com]', got '%v'", found)
+	}
+
+	if failures != 1 {
+		t.Fatalf("expected '1' failure from the dropped connection, got '%v'", failures)
+	}
+}
@@ -2,11 +2,14 @@ package sources
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/subfinder/research/core"
 	"golang.org/x/sync/semaphore"
@@ -14,11 +17,129 @@ import (
 
 // CrtSh is a source to process subdomains from https://crt.sh
 type CrtSh struct {
-	lock *semaphore.Weighted
+	IssuedAfter time.Time // Skip certificates issued before this time, if set.
+	lock        *semaphore.Weighted
 }
 
 type crtshObject struct {
-	NameValue string `json:"name_value"`
+	ID           int64  `json:"id"`
+	IssuerName   string `json:"issuer_name"`
+	CommonName   string `json:"common_name"`
+	NameValue    string `json:"name_value"`
+	NotBefore    string `json:"not_before"`
+	NotAfter     string `json:"not_after"`
+	SerialNumber string `json:"serial_number"`
+}
+
+// crtshTimeLayout is the format of the dates in the crt.sh JSON output, which are in UTC.
+const crtshTimeLayout = "2006-01-02T15:04:05"
+
+func parseCrtshTime(value string) (time.Time, error) {
+	t, err := time.Parse(crtshTimeLayout, value)
+	if err != nil {
+		return time.Parse(time.RFC3339, value)
+	}
+	return t, nil
+}
+
+// certificate returns the metadata of the certificate the object describes.
+func (object *crtshObject) certificate() (*core.Certificate, error) {
+	notBefore, err := parseCrtshTime(object.NotBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	notAfter, err := parseCrtshTime(object.NotAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &core.Certificate{
+		ID:        strconv.FormatInt(object.ID, 10),
+		Issuer:    object.IssuerName,
+		NotBefore: notBefore,
+		NotAfter:  notAfter,
+		Serial:    object.SerialNumber,
+		Expired:   time.Now().After(notAfter),
+	}, nil
+}
+
+// decodeResults streams the JSON objects from the given crt.sh response body, which
+// can either be an array or a series of concatenated objects, sending a Result for each
+// name in scope that passes the configured certificate filters.
+func (source *CrtSh) decodeResults(ctx context.Context, body io.Reader, domain string, results chan *core.Result) {
+	domainExtractor := core.NewSingleSubdomainExtractor(domain)
+
+	reader := bufio.NewReader(body)
+
+	// skip any leading whitespace to see how the objects are wrapped
+	for {
+		next, err := reader.Peek(1)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
+			return
+		}
+		if next[0] != ' ' && next[0] != '\t' && next[0] != '\r' && next[0] != '\n' {
+			break
+		}
+		reader.ReadByte()
+	}
+
+	decoder := json.NewDecoder(reader)
+
+	if next, _ := reader.Peek(1); next[0] == '[' {
+		if _, err := decoder.Token(); err != nil {
+			sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
+			return
+		}
+	}
+
+	for decoder.More() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		object := &crtshObject{}
+
+		err := decoder.Decode(object)
+		if err != nil {
+			core.Logger(ctx).Debug("parse failed", "offset", decoder.InputOffset(), "error", err)
+			sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
+			return
+		}
+
+		cert, err := object.certificate()
+		if err != nil {
+			core.Logger(ctx).Debug("skipping certificate", "id", object.ID, "error", err)
+			if !sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err)) {
+				return
+			}
+			continue
+		}
+
+		if !source.IssuedAfter.IsZero() && !cert.NotBefore.After(source.IssuedAfter) {
+			continue
+		}
+
+		// each record lists one or more names, one per line
+		seen := map[string]bool{}
+		for _, name := range append(strings.Split(object.NameValue, "\n"), object.CommonName) {
+			str := domainExtractor([]byte(strings.ToLower(strings.TrimSpace(name))))
+			if str == "" || seen[str] {
+				continue
+			}
+			seen[str] = true
+
+			result := core.NewResult(crtshLabel, str, nil)
+			result.SetCertificate(cert)
+			if !sendResultWithContext(ctx, results, result) {
+				return
+			}
+		}
+	}
 }
 
 // ProcessDomain takes a given base domain and attempts to enumerate subdomains.
@@ -32,14 +153,12 @@ func (source *CrtSh) ProcessDomain(ctx context.Context, domain string) <-chan *c
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
 			return
 		}
 		defer source.lock.Release(1)
 
-		domainExtractor := core.NewSingleSubdomainExtractor(domain)
-
 		req, err := http.NewRequest(http.MethodGet, "https://crt.sh/?q=%25."+domain+"&output=json", nil)
 		if err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
@@ -47,7 +166,7 @@ func (source *CrtSh) ProcessDomain(ctx context.Context, domain string) <-chan *c
 		}
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
@@ -61,48 +180,7 @@ func (source *CrtSh) ProcessDomain(ctx context.Context, domain string) <-chan *c
 			return
 		}
 
-		scanner := bufio.NewScanner(resp.Body)
-
-		scanner.Split(bufio.ScanBytes)
-
-		jsonBuffer := bytes.Buffer{}
-		for scanner.Scan() {
-			if ctx.Err() != nil {
-				return
-			}
-			jsonBuffer.Write(scanner.Bytes())
-			if scanner.Bytes()[0] == 125 { // if "}"
-				object := &crtshObject{}
-				json.Unmarshal(jsonBuffer.Bytes(), &object)
-				err = json.Unmarshal(jsonBuffer.Bytes(), &object)
-				jsonBuffer.Reset()
-				if err != nil {
-					sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
-					continue
-				}
-				// This could potentially be made more efficient.
-				str := domainExtractor([]byte(object.NameValue))
-				if str != "" {
-					if !sendResultWithContext(ctx, results, core.NewResult(crtshLabel, str, nil)) {
-						return
-					}
-				}
-			}
-
-			err = scanner.Err()
-
-			if err != nil {
-				sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
-				return
-			}
-		}
-
-		err = scanner.Err()
-
-		if err != nil {
-			sendResultWithContext(ctx, results, core.NewResult(crtshLabel, nil, err))
-			return
-		}
+		source.decodeResults(ctx, resp.Body, domain, results)
 	}(domain, results)
 	return results
 }
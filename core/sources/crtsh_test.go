@@ -3,6 +3,8 @@ package sources
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,6 +56,57 @@ func TestCrtShRecursive(t *testing.T) {
 	fmt.Println(len(results), ctx.Err())
 }
 
+func TestCrtShDecodeResults(t *testing.T) {
+	future := time.Now().AddDate(1, 0, 0).UTC().Format(crtshTimeLayout)
+
+	array := `[
+  {"id":1,"issuer_name":"C=US, O=Let's Encrypt, CN=R3","common_name":"www.example.com","name_value":"www.example.com\nmail.example.com\nexample.org","not_before":"2024-02-01T00:00:00","not_after":"` + future + `","serial_number":"0a"},
+  {"id":2,"issuer_name":"C=US, O=Let's Encrypt, CN=R3","common_name":"old.example.com","name_value":"old.example.com","not_before":"2017-01-01T00:00:00","not_after":"2017-04-01T00:00:00","serial_number":"0b"},
+  {"id":3,"issuer_name":"C=US, O=Let's Encrypt, CN=R3","common_name":"","name_value":"api.example.com","not_before":"2023-06-01T00:00:00","not_after":"` + future + `","serial_number":"0c"}
+]`
+
+	concatenated := strings.Replace(strings.Trim(array, "[]\n"), "},\n", "}", -1)
+
+	var units = []struct {
+		source *CrtSh
+		body   string
+		exp    []string
+	}{
+		{&CrtSh{}, array, []string{"api.example.com", "mail.example.com", "old.example.com", "www.example.com"}},
+		{&CrtSh{}, concatenated, []string{"api.example.com", "mail.example.com", "old.example.com", "www.example.com"}},
+		{&CrtSh{IssuedAfter: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}, array, []string{"mail.example.com", "www.example.com"}},
+	}
+
+	for _, u := range units {
+		results := make(chan *core.Result)
+		go func() {
+			defer close(results)
+			u.source.decodeResults(context.Background(), strings.NewReader(u.body), "example.com", results)
+		}()
+
+		got := []string{}
+		for result := range results {
+			if result.IsFailure() {
+				t.Fatal(result.Failure)
+			}
+			cert := result.GetCertificate()
+			if cert == nil || cert.ID == "" || cert.Issuer != "C=US, O=Let's Encrypt, CN=R3" {
+				t.Fatalf("expected certificate metadata, got '%v'", cert)
+			}
+			// dropped by EnumerationOptions.ExcludeExpired
+			if expired := result.Success == "old.example.com"; cert.Expired != expired {
+				t.Fatalf("expected the certificate of %v to be expired: %v", result.Success, expired)
+			}
+			got = append(got, result.Success.(string))
+		}
+		sort.Strings(got)
+
+		if strings.Join(got, ",") != strings.Join(u.exp, ",") {
+			t.Fatalf("expected '%v', got '%v'", u.exp, got)
+		}
+	}
+}
+
 //func TestCrtSh_MultiThreaded(t *testing.T) {
 //	domains := []string{"google.com", "bing.com", "yahoo.com", "duckduckgo.com"}
 //	source := CrtSh{}
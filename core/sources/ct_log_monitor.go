@@ -0,0 +1,133 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/subfinder/research/core"
+)
+
+// CTLogMonitor is a core.Monitor which tails certificate transparency logs
+// by polling their signed tree head, fetching every entry added since the
+// last poll and sending the names which are under any of the watched domains.
+type CTLogMonitor struct {
+	LogURLs      []string      // Base URLs of the logs, like https://ct.googleapis.com/logs/argon2024
+	PollInterval time.Duration // How often the tree heads are fetched, defaults to 10 seconds.
+	BatchSize    int64         // Number of entries asked for per get-entries request, defaults to 256.
+	HTTPClient   *http.Client  // Defaults to core.HTTPClient.
+}
+
+// tailLog follows a single log, starting at its current tree size, until the context is canceled.
+// Any errors are sent as failed results and retried with a backoff.
+func (monitor *CTLogMonitor) tailLog(ctx context.Context, logURL string, domains []string, results chan *core.Result) {
+	logURL = strings.TrimSuffix(logURL, "/")
+
+	matches := newDomainsMatcher(domains)
+
+	logger := core.Logger(ctx).With("log", logURL)
+
+	position := int64(-1)
+	backoff := time.Second
+
+	fail := func(err error) bool {
+		if !sendResultWithContext(ctx, results, core.NewResult(ctlogsLabel, nil, err)) {
+			return false
+		}
+		logger.Debug("retrying", "backoff", backoff, "error", err)
+		if !sleepWithContext(ctx, backoff) {
+			return false
+		}
+		backoff = nextBackoff(backoff)
+		return true
+	}
+
+	for ctx.Err() == nil {
+		sth := &ctSignedTreeHead{}
+		err := getJSON(ctx, monitor.HTTPClient, logURL+"/ct/v1/get-sth", sth)
+		if err != nil {
+			if !fail(err) {
+				return
+			}
+			continue
+		}
+
+		// only certificates issued from now on are interesting
+		if position < 0 {
+			position = sth.TreeSize
+		}
+
+		canceled := false
+		position, err = fetchCTEntries(ctx, monitor.HTTPClient, logURL, position, sth.TreeSize, monitor.BatchSize, func(metadata *core.Certificate, names []string) bool {
+			for _, name := range names {
+				domain := matches(name)
+				if domain == "" {
+					continue
+				}
+				result := core.NewResult(ctlogsLabel, name, nil)
+				result.SetDomain(domain)
+				result.SetCertificate(metadata)
+				if !sendResultWithContext(ctx, results, result) {
+					canceled = true
+					return false
+				}
+			}
+			return true
+		})
+		if canceled {
+			return
+		}
+
+		if err != nil {
+			if !fail(err) {
+				return
+			}
+			continue
+		}
+
+		backoff = time.Second
+
+		if !sleepWithContext(ctx, monitor.PollInterval) {
+			return
+		}
+	}
+}
+
+// Monitor follows every configured log until the given context is canceled,
+// sending new names under the given domains down the returned results channel.
+func (monitor *CTLogMonitor) Monitor(ctx context.Context, domains []string) <-chan *core.Result {
+	if monitor.PollInterval <= 0 {
+		monitor.PollInterval = 10 * time.Second
+	}
+
+	if monitor.BatchSize <= 0 {
+		monitor.BatchSize = 256
+	}
+
+	if monitor.HTTPClient == nil {
+		monitor.HTTPClient = core.HTTPClient
+	}
+
+	results := make(chan *core.Result)
+
+	wg := sync.WaitGroup{}
+
+	for _, logURL := range monitor.LogURLs {
+		wg.Add(1)
+		go func(logURL string) {
+			defer wg.Done()
+			monitor.tailLog(ctx, logURL, domains, results)
+		}(logURL)
+	}
+
+	go func() {
+		defer close(results)
+		wg.Wait()
+		// keep the promise of only closing once canceled, even without any logs
+		<-ctx.Done()
+	}()
+
+	return results
+}
@@ -0,0 +1,50 @@
+package sources
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCTLogMonitor(t *testing.T) {
+	log := &fakeCTLog{}
+	log.add(t, false, "old.example.com")
+
+	server := httptest.NewServer(log)
+	defer server.Close()
+
+	monitor := &CTLogMonitor{
+		LogURLs:      []string{server.URL},
+		PollInterval: 50 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	results := monitor.Monitor(ctx, []string{"example.com", "example.net"})
+
+	// give the monitor a chance to see the current tree size first
+	time.Sleep(200 * time.Millisecond)
+
+	log.add(t, false, "www.example.org")
+	log.add(t, true, "new.example.com", "www.example.net")
+
+	found := []string{}
+	for result := range results {
+		if result.IsFailure() {
+			t.Fatal(result.Failure)
+		}
+		if result.GetCertificate() == nil {
+			t.Fatal("expected certificate metadata")
+		}
+		found = append(found, result.Success.(string))
+		if len(found) == 2 {
+			cancel()
+		}
+	}
+
+	if len(found) != 2 || found[0] != "new.example.com" || found[1] != "www.example.net" {
+		t.Fatalf("expected '[new.example.com www.example.net]', got '%v'", found)
+	}
+}
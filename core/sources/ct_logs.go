@@ -0,0 +1,448 @@
+package sources
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/subfinder/research/core"
+	"golang.org/x/sync/semaphore"
+)
+
+// CTLogs is a source which talks to certificate transparency logs directly
+// through the RFC 6962 get-sth and get-entries endpoints, instead of going
+// through an intermediary like crt.sh. The names under the domains asked for
+// found in the fetched certificates are kept in an index, optionally saved to
+// IndexPath, so later runs only need to fetch the entries added to the logs since.
+//
+// Logs hold billions of entries, so the first run only fetches the last
+// InitialEntries of each log, and names under a domain are only indexed from
+// the first run asking for it on. Names are dropped from the index once their
+// certificate has been expired for longer than KeepExpired.
+type CTLogs struct {
+	LogURLs        []string      // Base URLs of the logs, like https://ct.googleapis.com/logs/argon2024
+	IndexPath      string        // File to keep the index in between runs, kept in memory if empty.
+	BatchSize      int64         // Number of entries asked for per get-entries request, defaults to 256.
+	InitialEntries int64         // Number of the most recent entries to fetch from a log seen for the first time, defaults to 10000.
+	KeepExpired    time.Duration // How long names are kept after their certificate expired, defaults to 30 days.
+	HTTPClient     *http.Client  // Defaults to core.HTTPClient.
+	index          *ctIndex
+	mutex          sync.Mutex
+	lock           *semaphore.Weighted
+}
+
+// ctIndex is what the CTLogs source keeps between runs.
+type ctIndex struct {
+	Positions map[string]int64             `json:"positions"` // Next entry to fetch for each log.
+	Domains   map[string]bool              `json:"domains"`   // Domains names are indexed for.
+	Names     map[string]*core.Certificate `json:"names"`     // Names found, with the most recent certificate they were on.
+}
+
+type ctSignedTreeHead struct {
+	TreeSize  int64 `json:"tree_size"`
+	Timestamp int64 `json:"timestamp"`
+}
+
+type ctEntries struct {
+	Entries []struct {
+		LeafInput []byte `json:"leaf_input"`
+		ExtraData []byte `json:"extra_data"`
+	} `json:"entries"`
+}
+
+// readUint24Prefixed reads a TLS style opaque vector with a 3 byte length prefix.
+func readUint24Prefixed(data []byte) ([]byte, []byte, error) {
+	if len(data) < 3 {
+		return nil, nil, errors.New("short length prefix")
+	}
+	length := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+	if len(data) < 3+length {
+		return nil, nil, errors.New("short vector")
+	}
+	return data[3 : 3+length], data[3+length:], nil
+}
+
+// ParseCTLogEntry parses the certificate out of a get-entries leaf_input and extra_data,
+// as described in RFC 6962 section 3.4. For precertificate entries the precertificate
+// from the extra_data is returned, which still carries the poison extension.
+func ParseCTLogEntry(leafInput, extraData []byte) (*x509.Certificate, error) {
+	// version (1), leaf type (1), timestamp (8), entry type (2)
+	if len(leafInput) < 12 {
+		return nil, errors.New("short merkle tree leaf")
+	}
+
+	if leafInput[0] != 0 || leafInput[1] != 0 {
+		return nil, fmt.Errorf("unsupported merkle tree leaf version %d type %d", leafInput[0], leafInput[1])
+	}
+
+	var der []byte
+	var err error
+
+	switch entryType := binary.BigEndian.Uint16(leafInput[10:12]); entryType {
+	case 0: // x509_entry
+		der, _, err = readUint24Prefixed(leafInput[12:])
+	case 1: // precert_entry
+		der, _, err = readUint24Prefixed(extraData)
+	default:
+		return nil, fmt.Errorf("unsupported log entry type %d", entryType)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+// getJSON decodes the JSON response body from the given URL into the given object.
+func getJSON(ctx context.Context, client *http.Client, url string, object interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return errors.New(resp.Status)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(object)
+}
+
+// getCTEntries fetches the entries between start and end from the given log,
+// which may return fewer entries than asked for.
+func getCTEntries(ctx context.Context, client *http.Client, logURL string, start, end int64) (*ctEntries, error) {
+	entries := &ctEntries{}
+	err := getJSON(ctx, client, logURL+"/ct/v1/get-entries?start="+strconv.FormatInt(start, 10)+"&end="+strconv.FormatInt(end, 10), entries)
+	if err != nil {
+		return nil, err
+	}
+
+	// logs are allowed to return fewer entries than asked for, but not none
+	if len(entries.Entries) == 0 {
+		return nil, fmt.Errorf("no entries returned from %v at %d", logURL, start)
+	}
+
+	return entries, nil
+}
+
+// fetchCTEntries fetches the entries of the given log from start up to the tree
+// size, batchSize at a time, calling found with the metadata and the names, lower
+// cased and without wildcards, of the certificate of every entry. It returns the
+// position after the last entry fetched, which is before the tree size when an
+// error is returned, or when found returns false.
+func fetchCTEntries(ctx context.Context, client *http.Client, logURL string, start, treeSize, batchSize int64, found func(metadata *core.Certificate, names []string) bool) (int64, error) {
+	logger := core.Logger(ctx).With("log", logURL)
+	position := start
+
+	for position < treeSize {
+		if ctx.Err() != nil {
+			return position, ctx.Err()
+		}
+
+		end := position + batchSize - 1
+		if end >= treeSize {
+			end = treeSize - 1
+		}
+
+		entries, err := getCTEntries(ctx, client, logURL, position, end)
+		if err != nil {
+			return position, err
+		}
+		logger.Log(ctx, core.LevelTrace, "fetched entries", "start", position, "entries", len(entries.Entries), "tree_size", treeSize)
+
+		for i, entry := range entries.Entries {
+			cert, err := ParseCTLogEntry(entry.LeafInput, entry.ExtraData)
+			if err != nil {
+				// skip entries that can't be parsed, they won't get any better
+				logger.Debug("skipping entry", "entry", position+int64(i), "error", err)
+				continue
+			}
+			metadata := core.NewCertificate(cert)
+			metadata.ID = logURL + "#" + strconv.FormatInt(position+int64(i), 10)
+
+			names := []string{}
+			seen := map[string]bool{}
+			for _, name := range core.CertificateNames(cert) {
+				name = strings.ToLower(strings.TrimPrefix(name, "*."))
+				if !seen[name] {
+					seen[name] = true
+					names = append(names, name)
+				}
+			}
+			if !found(metadata, names) {
+				return position + int64(i) + 1, nil
+			}
+		}
+
+		position += int64(len(entries.Entries))
+	}
+
+	return position, nil
+}
+
+// loadIndex reads the index from IndexPath, if there is one.
+func (source *CTLogs) loadIndex() error {
+	source.index = &ctIndex{
+		Positions: map[string]int64{},
+		Domains:   map[string]bool{},
+		Names:     map[string]*core.Certificate{},
+	}
+
+	if source.IndexPath == "" {
+		return nil
+	}
+
+	file, err := os.Open(source.IndexPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = json.NewDecoder(file).Decode(source.index)
+	if err != nil {
+		return err
+	}
+
+	if source.index.Positions == nil {
+		source.index.Positions = map[string]int64{}
+	}
+
+	if source.index.Domains == nil {
+		source.index.Domains = map[string]bool{}
+	}
+
+	if source.index.Names == nil {
+		source.index.Names = map[string]*core.Certificate{}
+	}
+
+	return nil
+}
+
+// pruneIndex drops the names which aren't under any of the indexed domains,
+// like those of indexes written before only these were kept, and the names
+// whose certificate expired longer than KeepExpired ago.
+func (source *CTLogs) pruneIndex() {
+	indexed := newDomainsMatcher(source.indexedDomains())
+	expired := time.Now().Add(-source.KeepExpired)
+	for name, cert := range source.index.Names {
+		if indexed(name) == "" || cert.NotAfter.Before(expired) {
+			delete(source.index.Names, name)
+		}
+	}
+}
+
+// saveIndex atomically writes the index to IndexPath, if there is one.
+func (source *CTLogs) saveIndex() error {
+	if source.IndexPath == "" {
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(source.IndexPath), filepath.Base(source.IndexPath)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = json.NewEncoder(tmp).Encode(source.index)
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), source.IndexPath)
+}
+
+// indexedDomains returns the domains names are indexed for.
+func (source *CTLogs) indexedDomains() []string {
+	domains := []string{}
+	for domain := range source.index.Domains {
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+// updateLog fetches the entries added to the given log since the last update,
+// adding the names of the certificates in them under the indexed domains to the
+// index. The index is only locked while read and written, not while fetching.
+func (source *CTLogs) updateLog(ctx context.Context, logURL string, indexed func(name string) string) error {
+	logURL = strings.TrimSuffix(logURL, "/")
+
+	sth := &ctSignedTreeHead{}
+	err := getJSON(ctx, source.HTTPClient, logURL+"/ct/v1/get-sth", sth)
+	if err != nil {
+		return err
+	}
+
+	source.mutex.Lock()
+	position, seen := source.index.Positions[logURL]
+	source.mutex.Unlock()
+	if !seen {
+		position = sth.TreeSize - source.InitialEntries
+		if position < 0 {
+			position = 0
+		}
+	}
+
+	found := map[string]*core.Certificate{}
+	position, err = fetchCTEntries(ctx, source.HTTPClient, logURL, position, sth.TreeSize, source.BatchSize, func(metadata *core.Certificate, names []string) bool {
+		for _, name := range names {
+			if indexed(name) == "" {
+				continue
+			}
+			if previous, ok := found[name]; !ok || previous.NotAfter.Before(metadata.NotAfter) {
+				found[name] = metadata
+			}
+		}
+		return true
+	})
+
+	// what was fetched before any error is kept, concurrent updates only move the position forward
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+	for name, metadata := range found {
+		if previous, ok := source.index.Names[name]; !ok || previous.NotAfter.Before(metadata.NotAfter) {
+			source.index.Names[name] = metadata
+		}
+	}
+	if current, ok := source.index.Positions[logURL]; !ok || current < position {
+		source.index.Positions[logURL] = position
+	}
+
+	return err
+}
+
+// update adds the given domain to the indexed ones and brings the index up to
+// date with every configured log.
+func (source *CTLogs) update(ctx context.Context, domain string) []error {
+	errs := []error{}
+
+	source.mutex.Lock()
+	if source.index == nil {
+		if err := source.loadIndex(); err != nil {
+			source.index = nil
+			source.mutex.Unlock()
+			return append(errs, err)
+		}
+	}
+	source.index.Domains[domain] = true
+	indexed := newDomainsMatcher(source.indexedDomains())
+	source.mutex.Unlock()
+
+	for _, logURL := range source.LogURLs {
+		if err := source.updateLog(ctx, logURL, indexed); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", logURL, err))
+		}
+	}
+
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	source.pruneIndex()
+
+	if err := source.saveIndex(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// ProcessDomain takes a given base domain and attempts to enumerate subdomains.
+func (source *CTLogs) ProcessDomain(ctx context.Context, domain string) <-chan *core.Result {
+	source.mutex.Lock()
+	if source.lock == nil {
+		source.lock = defaultLockValue()
+	}
+
+	if source.BatchSize <= 0 {
+		source.BatchSize = 256
+	}
+
+	if source.InitialEntries <= 0 {
+		source.InitialEntries = 10000
+	}
+
+	if source.KeepExpired <= 0 {
+		source.KeepExpired = 30 * 24 * time.Hour
+	}
+
+	if source.HTTPClient == nil {
+		source.HTTPClient = core.HTTPClient
+	}
+	source.mutex.Unlock()
+
+	results := make(chan *core.Result)
+
+	go func(domain string, results chan *core.Result) {
+		defer close(results)
+
+		if len(source.LogURLs) == 0 {
+			sendResultWithContext(ctx, results, core.NewResult(ctlogsLabel, nil, errors.New("no log urls")))
+			return
+		}
+
+		if err := acquireLock(ctx, source.lock); err != nil {
+			sendResultWithContext(ctx, results, core.NewResult(ctlogsLabel, nil, err))
+			return
+		}
+		defer source.lock.Release(1)
+
+		for _, err := range source.update(ctx, core.NormalizeName(domain)) {
+			if !sendResultWithContext(ctx, results, core.NewResult(ctlogsLabel, nil, err)) {
+				return
+			}
+		}
+
+		domainExtractor := core.NewSingleSubdomainExtractor(domain)
+
+		// collect the matches first, so the index isn't locked while sending
+		source.mutex.Lock()
+		found := []*core.Result{}
+		var names map[string]*core.Certificate
+		if source.index != nil {
+			names = source.index.Names
+		}
+		for name, cert := range names {
+			if str := domainExtractor([]byte(name)); str != "" && str == name {
+				// the index may be older than the certificate's expiry
+				metadata := *cert
+				metadata.Expired = time.Now().After(metadata.NotAfter)
+				result := core.NewResult(ctlogsLabel, str, nil)
+				result.SetCertificate(&metadata)
+				found = append(found, result)
+			}
+		}
+		source.mutex.Unlock()
+
+		for _, result := range found {
+			if !sendResultWithContext(ctx, results, result) {
+				return
+			}
+		}
+	}(domain, results)
+	return results
+}
@@ -0,0 +1,259 @@
+package sources
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/subfinder/research/core"
+)
+
+// uint24Prefixed encodes the given data as a TLS style opaque vector with a 3 byte length.
+func uint24Prefixed(data []byte) []byte {
+	return append([]byte{byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}, data...)
+}
+
+type fakeCTEntry struct {
+	LeafInput []byte `json:"leaf_input"`
+	ExtraData []byte `json:"extra_data"`
+}
+
+// newFakeCTEntry creates a get-entries entry for a certificate, or a precertificate
+// when precert is true.
+func newFakeCTEntry(der []byte, precert bool) fakeCTEntry {
+	leaf := []byte{0, 0}
+	leaf = append(leaf, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(leaf[2:], uint64(time.Now().UnixNano()/int64(time.Millisecond)))
+	if precert {
+		leaf = append(leaf, 0, 1)
+		leaf = append(leaf, make([]byte, 32)...) // issuer key hash
+		leaf = append(leaf, uint24Prefixed([]byte("tbs certificate"))...)
+		leaf = append(leaf, 0, 0) // extensions
+		return fakeCTEntry{LeafInput: leaf, ExtraData: append(uint24Prefixed(der), 0, 0, 0)}
+	}
+	leaf = append(leaf, 0, 0)
+	leaf = append(leaf, uint24Prefixed(der)...)
+	leaf = append(leaf, 0, 0) // extensions
+	return fakeCTEntry{LeafInput: leaf, ExtraData: []byte{0, 0, 0}}
+}
+
+// fakeCTLog is a stand-in for a certificate transparency log, which only
+// hands out two entries per get-entries request like a real log might.
+type fakeCTLog struct {
+	sync.Mutex
+	entries  []fakeCTEntry
+	requests []string
+}
+
+func (log *fakeCTLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	log.Lock()
+	defer log.Unlock()
+
+	log.requests = append(log.requests, r.URL.RequestURI())
+
+	switch r.URL.Path {
+	case "/ct/v1/get-sth":
+		json.NewEncoder(w).Encode(map[string]interface{}{"tree_size": len(log.entries), "timestamp": 0})
+	case "/ct/v1/get-entries":
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		end, _ := strconv.Atoi(r.URL.Query().Get("end"))
+		if end >= len(log.entries) || start > end {
+			http.Error(w, "bad range", http.StatusBadRequest)
+			return
+		}
+		if end-start > 1 {
+			end = start + 1
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"entries": log.entries[start : end+1]})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (log *fakeCTLog) add(t *testing.T, precert bool, names ...string) {
+	template := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: names[0]},
+		DNSNames:  names,
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	if precert {
+		template.ExtraExtensions = []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}, Critical: true, Value: []byte{5, 0}}}
+	}
+	der, _ := newTestCertificate(t, template)
+
+	log.Lock()
+	defer log.Unlock()
+	log.entries = append(log.entries, newFakeCTEntry(der, precert))
+}
+
+func collectCTLogs(t *testing.T, source *CTLogs, domain string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	found := []string{}
+	for result := range source.ProcessDomain(ctx, domain) {
+		if result.IsFailure() {
+			t.Fatal(result.Failure)
+		}
+		found = append(found, result.Success.(string))
+	}
+	sort.Strings(found)
+	return found
+}
+
+func TestCTLogs(t *testing.T) {
+	log := &fakeCTLog{}
+	log.add(t, false, "www.example.com", "example.com")
+	log.add(t, true, "*.api.example.com")
+	log.add(t, false, "www.example.org")
+
+	server := httptest.NewServer(log)
+	defer server.Close()
+
+	index := filepath.Join(t.TempDir(), "ct-index.json")
+
+	source := &CTLogs{LogURLs: []string{server.URL + "/"}, IndexPath: index}
+
+	found := collectCTLogs(t, source, "example.com")
+	if strings.Join(found, ",") != "api.example.com,www.example.com" {
+		t.Fatalf("expected '[api.example.com www.example.com]', got '%v'", found)
+	}
+
+	// a new source only fetches what was added since, using the saved index
+	log.add(t, false, "mail.example.com")
+	log.requests = nil
+
+	source = &CTLogs{LogURLs: []string{server.URL}, IndexPath: index}
+
+	found = collectCTLogs(t, source, "example.com")
+	if strings.Join(found, ",") != "api.example.com,mail.example.com,www.example.com" {
+		t.Fatalf("expected '[api.example.com mail.example.com www.example.com]', got '%v'", found)
+	}
+
+	if fmt.Sprint(log.requests) != "[/ct/v1/get-sth /ct/v1/get-entries?start=3&end=3]" {
+		t.Fatalf("expected only the new entry to be fetched, got '%v'", log.requests)
+	}
+}
+
+func TestCTLogs_Index(t *testing.T) {
+	log := &fakeCTLog{}
+	log.add(t, false, "www.example.com", "www.example.org")
+
+	server := httptest.NewServer(log)
+	defer server.Close()
+
+	// an index with a name of another domain, and one expired for too long
+	index := filepath.Join(t.TempDir(), "ct-index.json")
+	old := &ctIndex{
+		Domains: map[string]bool{"example.com": true},
+		Names: map[string]*core.Certificate{
+			"old.example.com": {NotAfter: time.Now().Add(-60 * 24 * time.Hour)},
+			"new.example.com": {NotAfter: time.Now().Add(-24 * time.Hour)},
+			"www.example.net": {NotAfter: time.Now().Add(time.Hour)},
+		},
+	}
+	data, _ := json.Marshal(old)
+	if err := os.WriteFile(index, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := &CTLogs{LogURLs: []string{server.URL}, IndexPath: index, InitialEntries: 1}
+	found := collectCTLogs(t, source, "example.com")
+	if strings.Join(found, ",") != "new.example.com,www.example.com" {
+		t.Fatalf("expected '[new.example.com www.example.com]', got '%v'", found)
+	}
+
+	saved := &ctIndex{}
+	data, _ = os.ReadFile(index)
+	if err := json.Unmarshal(data, saved); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for name := range saved.Names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "new.example.com,www.example.com" {
+		t.Fatalf("expected only the names under example.com to be kept, got '%v'", names)
+	}
+}
+
+func TestCTLogs_Concurrent(t *testing.T) {
+	log := &fakeCTLog{}
+	log.add(t, false, "www.example.com")
+
+	server := httptest.NewServer(log)
+	defer server.Close()
+
+	// domains only get the names logged after they were first enumerated
+	source := &CTLogs{LogURLs: []string{server.URL}}
+	for _, domain := range []string{"example.com", "example.org"} {
+		collectCTLogs(t, source, domain)
+	}
+
+	// domains enumerated at once update the same index
+	log.add(t, false, "api.example.com", "api.example.org")
+	found := map[string][]string{}
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for _, domain := range []string{"example.com", "example.org"} {
+		wg.Add(1)
+		go func(domain string) {
+			defer wg.Done()
+			names := collectCTLogs(t, source, domain)
+			mutex.Lock()
+			found[domain] = names
+			mutex.Unlock()
+		}(domain)
+	}
+	wg.Wait()
+
+	expected := map[string]string{
+		"example.com": "api.example.com,www.example.com",
+		"example.org": "api.example.org",
+	}
+	for domain, names := range expected {
+		if strings.Join(found[domain], ",") != names {
+			t.Fatalf("expected '%v' for %v, got '%v'", names, domain, found[domain])
+		}
+	}
+}
+
+func TestParseCTLogEntry(t *testing.T) {
+	der, _ := newTestCertificate(t, &x509.Certificate{
+		DNSNames:        []string{"pre.example.com"},
+		NotBefore:       time.Now(),
+		NotAfter:        time.Now().Add(time.Hour),
+		ExtraExtensions: []pkix.Extension{{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}, Critical: true, Value: []byte{5, 0}}},
+	})
+
+	entry := newFakeCTEntry(der, true)
+
+	cert, err := ParseCTLogEntry(entry.LeafInput, entry.ExtraData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !core.IsPrecertificate(cert) {
+		t.Fatal("expected a precertificate")
+	}
+
+	if _, err := ParseCTLogEntry(entry.LeafInput[:8], nil); err == nil {
+		t.Fatal("expected an error for a short leaf")
+	}
+}
@@ -22,7 +22,7 @@ type DNSDumpster struct {
 	lock *semaphore.Weighted
 }
 
-func getHTTPCookieResponse(urls string, cookies []*http.Cookie, timeout int) (resp *http.Response, cookie []*http.Cookie, err error) {
+func getHTTPCookieResponse(ctx context.Context, urls string, cookies []*http.Cookie, timeout int) (resp *http.Response, cookie []*http.Cookie, err error) {
 	var curCookieJar *cookiejar.Jar
 
 	curCookieJar, _ = cookiejar.New(nil)
@@ -32,12 +32,13 @@ func getHTTPCookieResponse(urls string, cookies []*http.Cookie, timeout int) (re
 	curCookieJar.SetCookies(u, cookies)
 
 	tr := &http.Transport{
+		Proxy:           core.Proxy,
 		TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
 		//TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 	}
 
 	client := &http.Client{
-		Transport: tr,
+		Transport: core.ApplyHeaders(core.CountRequests(tr)),
 		Jar:       curCookieJar,
 		Timeout:   time.Duration(timeout) * time.Second,
 	}
@@ -46,8 +47,8 @@ func getHTTPCookieResponse(urls string, cookies []*http.Cookie, timeout int) (re
 	if err != nil {
 		return resp, cookie, err
 	}
+	req = req.WithContext(ctx)
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; U; Linux i686; en-US; rv:1.9.0.1) Gecko/2008071615 Fedora/3.0.1-1.fc9 Firefox/3.0.1")
 	req.Header.Add("Connection", "close")
 
 	resp, err = client.Do(req)
@@ -71,7 +72,7 @@ func (source *DNSDumpster) ProcessDomain(ctx context.Context, domain string) <-c
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(dnsdumpsterLabel, nil, err))
 			return
 		}
@@ -85,7 +86,7 @@ func (source *DNSDumpster) ProcessDomain(ctx context.Context, domain string) <-c
 		curCookieJar, _ = cookiejar.New(nil)
 
 		// Make a http request to DNSDumpster
-		resp, gCookies, err := getHTTPCookieResponse("https://dnsdumpster.com", gCookies, 20)
+		resp, gCookies, err := getHTTPCookieResponse(ctx, "https://dnsdumpster.com", gCookies, 20)
 		if err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(dnsdumpsterLabel, nil, err))
 			return
@@ -122,10 +123,9 @@ func (source *DNSDumpster) ProcessDomain(ctx context.Context, domain string) <-c
 		req.PostForm = form
 		req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 		req.Header.Add("Referer", "https://dnsdumpster.com")
-		req.Header.Set("User-Agent", "Mozilla/5.0 (X11; U; Linux i686; en-US; rv:1.9.0.1) Gecko/2008071615 Fedora/3.0.1-1.fc9 Firefox/3.0.1")
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		core.HTTPClient.Jar = curCookieJar
 
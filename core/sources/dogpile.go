@@ -32,7 +32,7 @@ func (source *DogPile) ProcessDomain(ctx context.Context, domain string) <-chan
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(dogpileLabel, nil, err))
 			return
 		}
@@ -41,6 +41,8 @@ func (source *DogPile) ProcessDomain(ctx context.Context, domain string) <-chan
 		domainExtractor := core.NewSingleSubdomainExtractor(domain)
 
 		for currentPage := 1; currentPage <= 750; currentPage++ {
+			core.Logger(ctx).Log(ctx, core.LevelTrace, "fetching page", "page", currentPage)
+
 			url := "http://www.dogpile.com/search/web?q=" + domain + "&qsi=" + strconv.Itoa(currentPage*15+1)
 			req, err := http.NewRequest(http.MethodGet, url, nil)
 			if err != nil {
@@ -49,7 +51,7 @@ func (source *DogPile) ProcessDomain(ctx context.Context, domain string) <-chan
 			}
 
 			req.Cancel = ctx.Done()
-			req.WithContext(ctx)
+			req = req.WithContext(ctx)
 
 			resp, err := core.HTTPClient.Do(req)
 			if err != nil {
@@ -27,7 +27,7 @@ func (source *Entrust) ProcessDomain(ctx context.Context, domain string) <-chan
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(entrustLabel, nil, err))
 			return
 		}
@@ -42,7 +42,7 @@ func (source *Entrust) ProcessDomain(ctx context.Context, domain string) <-chan
 		}
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
@@ -28,7 +28,7 @@ func (source *HackerTarget) ProcessDomain(ctx context.Context, domain string) <-
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(hackertargetLabel, nil, err))
 			return
 		}
@@ -55,7 +55,7 @@ func (source *HackerTarget) ProcessDomain(ctx context.Context, domain string) <-
 		}
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err = core.HTTPClient.Do(req)
 		if err != nil {
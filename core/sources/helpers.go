@@ -3,6 +3,7 @@ package sources
 import (
 	"context"
 	"runtime"
+	"time"
 
 	"github.com/subfinder/research/core"
 	"golang.org/x/sync/semaphore"
@@ -17,6 +18,59 @@ func sendResultWithContext(ctx context.Context, results chan *core.Result, resul
 	}
 }
 
+// sleepWithContext waits for the given duration, returning false
+// if the context was canceled before then.
+func sleepWithContext(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// nextBackoff doubles the given backoff duration, up to a minute.
+func nextBackoff(backoff time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > time.Minute {
+		return time.Minute
+	}
+	return backoff
+}
+
+// newDomainsMatcher returns a function which checks if a given name is a subdomain
+// of any of the given domains, returning the domain it is under or an empty string.
+// The returned function is not safe to share between go routines, since the
+// extractors it uses are not either.
+func newDomainsMatcher(domains []string) func(name string) string {
+	extractors := []func([]byte) string{}
+	for _, domain := range domains {
+		extractors = append(extractors, core.NewSingleSubdomainExtractor(domain))
+	}
+	return func(name string) string {
+		if name == "" {
+			return ""
+		}
+		for i, extractor := range extractors {
+			if extractor([]byte(name)) == name {
+				return domains[i]
+			}
+		}
+		return ""
+	}
+}
+
+// acquireLock waits for a slot of the given lock, counting how long it
+// took as a wait for the rate limit of the source in the context.
+func acquireLock(ctx context.Context, lock *semaphore.Weighted) error {
+	start := time.Now()
+	err := lock.Acquire(ctx, 1)
+	core.RecordWait(ctx, time.Since(start))
+	return err
+}
+
 var maxWorkers = runtime.GOMAXPROCS(0)
 
 func defaultLockValue() *semaphore.Weighted {
@@ -31,8 +85,10 @@ var (
 	bingLabel             = "bing"
 	certdbLabel           = "certdb"
 	certspotterLabel      = "certspotter"
+	certstreamLabel       = "certstream"
 	commoncrawlLabel      = "commoncrawl"
 	crtshLabel            = "crtsh"
+	ctlogsLabel           = "ctlogs"
 	dnsdbdLabel           = "dnsdbd"
 	dnsdumpsterLabel      = "dnsdumpster"
 	dnstableLabel         = "dnstable"
@@ -46,6 +102,7 @@ var (
 	securitytrailsLabel   = "securitytrails"
 	threatcrowdLabel      = "threatcrowd"
 	threatminerLabel      = "threatminer"
+	tlscertificatesLabel  = "tlscertificates"
 	virustotalLabel       = "virustotal"
 	waybackarchiveLabel   = "waybackarchive"
 	yahooLabel            = "yahoo"
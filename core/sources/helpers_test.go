@@ -0,0 +1,30 @@
+package sources
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"testing"
+)
+
+// newTestCertificate creates a self-signed DER certificate from the given template,
+// filling in the serial number if it is missing.
+func newTestCertificate(t *testing.T, template *x509.Certificate) ([]byte, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if template.SerialNumber == nil {
+		template.SerialNumber = big.NewInt(1)
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return der, key
+}
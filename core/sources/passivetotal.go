@@ -42,7 +42,7 @@ func (source *Passivetotal) ProcessDomain(ctx context.Context, domain string) <-
 			return
 		}
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(passivetotalLabel, nil, err))
 			return
 		}
@@ -61,7 +61,7 @@ func (source *Passivetotal) ProcessDomain(ctx context.Context, domain string) <-
 		req.Header.Set("Content-Type", "application/json")
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
@@ -46,7 +46,7 @@ func (source *Riddler) Authenticate(ctx context.Context) (bool, error) {
 	req.Header.Add("Content-Type", "application/json")
 
 	req.Cancel = ctx.Done()
-	req.WithContext(ctx)
+	req = req.WithContext(ctx)
 
 	resp, err := core.HTTPClient.Do(req)
 	if err != nil {
@@ -81,7 +81,7 @@ func (source *Riddler) ProcessDomain(ctx context.Context, domain string) <-chan
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(riddlerLabel, nil, err))
 			return
 		}
@@ -109,6 +109,7 @@ func (source *Riddler) ProcessDomain(ctx context.Context, domain string) <-chan
 		domainExtractor := core.NewSingleSubdomainExtractor(domain)
 
 		var resp *http.Response
+		var req *http.Request
 		var err error
 
 		if source.APIToken != "" {
@@ -120,7 +121,7 @@ func (source *Riddler) ProcessDomain(ctx context.Context, domain string) <-chan
 			req.Header.Set("Content-type", "application/json")
 			req.Header.Set("Authentication-Token", source.APIToken)
 
-			req.WithContext(ctx)
+			req = req.WithContext(ctx)
 
 			resp, err := core.HTTPClient.Do(req)
 			if err != nil {
@@ -155,7 +156,13 @@ func (source *Riddler) ProcessDomain(ctx context.Context, domain string) <-chan
 
 		if source.APIToken == "" {
 			// not authenticated
-			resp, err = core.HTTPClient.Get("https://riddler.io/search/exportcsv?q=pld:" + domain)
+			req, err = http.NewRequest(http.MethodGet, "https://riddler.io/search/exportcsv?q=pld:"+domain, nil)
+			if err != nil {
+				sendResultWithContext(ctx, results, core.NewResult(riddlerLabel, nil, err))
+				return
+			}
+
+			resp, err = core.HTTPClient.Do(req.WithContext(ctx))
 			if err != nil {
 				sendResultWithContext(ctx, results, core.NewResult(riddlerLabel, nil, err))
 				return
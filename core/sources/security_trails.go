@@ -31,7 +31,7 @@ func (source *SecurityTrails) ProcessDomain(ctx context.Context, domain string)
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(securitytrailsLabel, nil, err))
 			return
 		}
@@ -53,7 +53,7 @@ func (source *SecurityTrails) ProcessDomain(ctx context.Context, domain string)
 		req.Header.Add("APIKEY", source.APIToken)
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
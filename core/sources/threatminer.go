@@ -26,7 +26,7 @@ func (source *Threatminer) ProcessDomain(ctx context.Context, domain string) <-c
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(threatminerLabel, nil, err))
 			return
 		}
@@ -41,7 +41,7 @@ func (source *Threatminer) ProcessDomain(ctx context.Context, domain string) <-c
 		}
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
@@ -0,0 +1,60 @@
+package sources
+
+import (
+	"context"
+	"time"
+
+	"github.com/subfinder/research/core"
+	"golang.org/x/sync/semaphore"
+)
+
+// TLSCertificates is an active source which performs TLS handshakes against
+// the given host and its IP addresses, both with and without SNI, to find
+// names in the certificate SANs and CN. This picks up names from private CAs
+// which never reach any certificate transparency log. The hosts found by other
+// sources are harvested the same way by a core.TLSHarvester.
+type TLSCertificates struct {
+	Ports    []int            // Defaults to common TLS ports.
+	Timeout  time.Duration    // Timeout for each handshake, defaults to 5 seconds.
+	Resolver core.TLSResolver // Defaults to net.DefaultResolver.
+	lock     *semaphore.Weighted
+}
+
+// ProcessDomain takes a given base domain and attempts to enumerate subdomains.
+func (source *TLSCertificates) ProcessDomain(ctx context.Context, domain string) <-chan *core.Result {
+	if source.lock == nil {
+		source.lock = defaultLockValue()
+	}
+
+	results := make(chan *core.Result)
+
+	go func(domain string, results chan *core.Result) {
+		defer close(results)
+
+		if err := acquireLock(ctx, source.lock); err != nil {
+			sendResultWithContext(ctx, results, core.NewResult(tlscertificatesLabel, nil, err))
+			return
+		}
+		defer source.lock.Release(1)
+
+		harvester := &core.TLSHarvester{Ports: source.Ports, Timeout: source.Timeout, Resolver: source.Resolver}
+		names, err := harvester.Harvest(ctx, domain)
+		if err != nil {
+			sendResultWithContext(ctx, results, core.NewResult(tlscertificatesLabel, nil, err))
+			return
+		}
+
+		subdomains, related := core.SplitHarvestedNames(domain, names)
+		for _, name := range subdomains {
+			if !sendResultWithContext(ctx, results, core.NewResult(tlscertificatesLabel, name, nil)) {
+				return
+			}
+		}
+		for _, name := range related {
+			if !sendResultWithContext(ctx, results, core.NewResult(tlscertificatesLabel, name, nil)) {
+				return
+			}
+		}
+	}(domain, results)
+	return results
+}
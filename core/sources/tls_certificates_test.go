@@ -0,0 +1,93 @@
+package sources
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/subfinder/research/core"
+)
+
+type fakeTLSResolver map[string][]string
+
+func (r fakeTLSResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	return r[host], nil
+}
+
+func newTestTLSListener(t *testing.T, commonName string, dnsNames []string) net.Listener {
+	der, key := newTestCertificate(t, &x509.Certificate{
+		Subject:   pkix.Name{CommonName: commonName},
+		DNSNames:  dnsNames,
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+	})
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}(conn)
+		}
+	}()
+
+	return listener
+}
+
+func TestTLSCertificates(t *testing.T) {
+	listener := newTestTLSListener(t, "www.example.com", []string{"www.example.com", "*.internal.example.com", "example.com", "example.org", "shop.example.org"})
+	defer listener.Close()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	source := &TLSCertificates{
+		Ports:    []int{p},
+		Resolver: fakeTLSResolver{"example.com": {"127.0.0.1"}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	found := []string{}
+	related := []string{}
+
+	for result := range source.ProcessDomain(ctx, "example.com") {
+		if result.IsFailure() {
+			t.Fatal(result.Failure)
+		}
+		switch success := result.Success.(type) {
+		case string:
+			found = append(found, success)
+		case core.RelatedDomain:
+			related = append(related, string(success))
+		}
+	}
+
+	sort.Strings(found)
+
+	if len(found) != 2 || found[0] != "internal.example.com" || found[1] != "www.example.com" {
+		t.Fatalf("expected '[internal.example.com www.example.com]', got '%v'", found)
+	}
+
+	if len(related) != 1 || related[0] != "example.org" {
+		t.Fatalf("expected '[example.org]', got '%v'", related)
+	}
+}
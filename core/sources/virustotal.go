@@ -31,7 +31,7 @@ func (source *Virustotal) ProcessDomain(ctx context.Context, domain string) <-ch
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(virustotalLabel, nil, err))
 			return
 		}
@@ -54,7 +54,7 @@ func (source *Virustotal) ProcessDomain(ctx context.Context, domain string) <-ch
 		}
 
 		req.Cancel = ctx.Done()
-		req.WithContext(ctx)
+		req = req.WithContext(ctx)
 
 		resp, err := core.HTTPClient.Do(req)
 		if err != nil {
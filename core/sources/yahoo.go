@@ -27,7 +27,7 @@ func (source *Yahoo) ProcessDomain(ctx context.Context, domain string) <-chan *c
 	go func(domain string, results chan *core.Result) {
 		defer close(results)
 
-		if err := source.lock.Acquire(ctx, 1); err != nil {
+		if err := acquireLock(ctx, source.lock); err != nil {
 			sendResultWithContext(ctx, results, core.NewResult(yahooLabel, nil, err))
 			return
 		}
@@ -36,6 +36,8 @@ func (source *Yahoo) ProcessDomain(ctx context.Context, domain string) <-chan *c
 		domainExtractor := core.NewSingleSubdomainExtractor(domain)
 
 		for currentPage := 1; currentPage <= 750; currentPage++ {
+			core.Logger(ctx).Log(ctx, core.LevelTrace, "fetching page", "page", currentPage)
+
 			url := "https://search.yahoo.com/search?p=site:" + domain + "&b=" + strconv.Itoa(currentPage*10) + "&pz=10&bct=0&xargs=0"
 
 			req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -45,7 +47,7 @@ func (source *Yahoo) ProcessDomain(ctx context.Context, domain string) <-chan *c
 			}
 
 			req.Cancel = ctx.Done()
-			req.WithContext(ctx)
+			req = req.WithContext(ctx)
 
 			resp, err := core.HTTPClient.Do(req)
 			if err != nil {
@@ -0,0 +1,363 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// StatsTable is the format of WriteStats for people, next to OutputJSON.
+const StatsTable = "table"
+
+// The states of a source in its SourceStats.
+const (
+	SourceQueued  = "queued"  // It hasn't started on any domain yet.
+	SourceRunning = "running" // It's enumerating a domain.
+	SourceDone    = "done"    // It finished every domain it started without errors.
+	SourceFailed  = "failed"  // It finished every domain it started, with errors.
+)
+
+// SourceStats are the statistics of a source, over every enumeration done with the same EnumerationOptions.
+type SourceStats struct {
+	Source     string         `json:"source"`
+	State      string         `json:"state"`      // One of SourceQueued, SourceRunning, SourceDone or SourceFailed.
+	Requests   int64          `json:"requests"`   // HTTP requests made.
+	Bytes      int64          `json:"bytes"`      // Bytes read from the bodies of HTTP responses.
+	Results    int            `json:"results"`    // Names found, including duplicates.
+	Unique     int            `json:"unique"`     // Distinct names found.
+	Duplicates int            `json:"duplicates"` // Names found again after the first time.
+	Exclusive  int            `json:"exclusive"`  // Distinct names no other source found.
+	Errors     map[string]int `json:"errors"`     // Failed results by ErrorKind.
+	Time       time.Duration  `json:"-"`          // Time spent enumerating, summed over the domains.
+	Latency    time.Duration  `json:"-"`          // Average time until the headers of HTTP responses.
+	Waited     time.Duration  `json:"-"`          // Time spent waiting for the source's rate limit.
+}
+
+// MarshalJSON encodes the durations as milliseconds.
+func (s *SourceStats) MarshalJSON() ([]byte, error) {
+	type sourceStats SourceStats
+	return json.Marshal(&struct {
+		*sourceStats
+		TimeMs    int64 `json:"time_ms"`
+		LatencyMs int64 `json:"latency_ms"`
+		WaitedMs  int64 `json:"waited_ms"`
+	}{(*sourceStats)(s), s.Time.Milliseconds(), s.Latency.Milliseconds(), s.Waited.Milliseconds()})
+}
+
+// ErrorCount returns the number of failed results.
+func (s *SourceStats) ErrorCount() int {
+	count := 0
+	for _, n := range s.Errors {
+		count += n
+	}
+	return count
+}
+
+// Stats are the statistics of every source, as returned by EnumerationOptions.Stats.
+type Stats struct {
+	Names   int            `json:"names"`   // Distinct names found by the sources together.
+	Sources []*SourceStats `json:"sources"` // In the order of EnumerationOptions.Sources.
+}
+
+// WriteStats writes the statistics as a table with a row for each source, or as JSON.
+func WriteStats(w io.Writer, format string, stats *Stats) error {
+	switch format {
+	case StatsTable, "":
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "SOURCE\tSTATE\tREQUESTS\tBYTES\tRESULTS\tUNIQUE\tDUPLICATES\tEXCLUSIVE\tERRORS\tTIME\tLATENCY\tWAITED")
+		for _, source := range stats.Sources {
+			fmt.Fprintf(table, "%v\t%v\t%d\t%d\t%d\t%d\t%d\t%d\t%v\t%v\t%v\t%v\n",
+				source.Source, source.State, source.Requests, source.Bytes, source.Results, source.Unique, source.Duplicates, source.Exclusive,
+				formatErrors(source.Errors), source.Time.Round(time.Millisecond), source.Latency.Round(time.Millisecond), source.Waited.Round(time.Millisecond))
+		}
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "%d distinct names from %d sources\n", stats.Names, len(stats.Sources))
+		return err
+	case OutputJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	return fmt.Errorf("unknown stats format %q, expected table or json", format)
+}
+
+// formatErrors formats error counts by kind, like "3 (http_status 2, timeout 1)".
+func formatErrors(errors map[string]int) string {
+	total := 0
+	kinds := []string{}
+	for kind, count := range errors {
+		total += count
+		kinds = append(kinds, fmt.Sprintf("%v %d", kind, count))
+	}
+	if total == 0 {
+		return "0"
+	}
+	sort.Strings(kinds)
+	return fmt.Sprintf("%d (%v)", total, strings.Join(kinds, ", "))
+}
+
+// sourceStatsKey is the context key of the sourceCounters of the source a request is made for.
+type sourceStatsKey struct{}
+
+// sourceCounters collects the statistics of a source while it's enumerating,
+// also counting them in its Metrics, if any.
+type sourceCounters struct {
+	name       string
+	metrics    *Metrics
+	mutex      sync.Mutex
+	requests   int64
+	bytes      int64
+	results    int
+	duplicates int
+	names      map[string]bool
+	errors     map[string]int
+	running    int // Domains being enumerated.
+	runs       int // Domains started.
+	time       time.Duration
+	latency    time.Duration
+	waited     time.Duration
+}
+
+// start counts a domain the source started on, returning when.
+func (c *sourceCounters) start() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.running++
+	c.runs++
+	return time.Now()
+}
+
+func (c *sourceCounters) request(status int, latency time.Duration) {
+	if c.metrics != nil {
+		c.metrics.request(c.name, status, latency)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.requests++
+	c.latency += latency
+}
+
+func (c *sourceCounters) wait(wait time.Duration) {
+	if c.metrics != nil {
+		c.metrics.wait(c.name, wait)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.waited += wait
+}
+
+func (c *sourceCounters) read(n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.bytes += int64(n)
+}
+
+func (c *sourceCounters) finish(elapsed time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.running--
+	c.time += elapsed
+}
+
+// state returns the state of the source, see SourceStats.
+func (c *sourceCounters) state() string {
+	switch {
+	case c.running > 0:
+		return SourceRunning
+	case c.runs == 0:
+		return SourceQueued
+	case len(c.errors) > 0:
+		return SourceFailed
+	}
+	return SourceDone
+}
+
+// statsCollector collects the statistics of the sources of an EnumerationOptions.
+type statsCollector struct {
+	metrics *Metrics
+	mutex   sync.Mutex
+	sources map[string]*sourceCounters
+	found   map[string]int // Number of sources which found each name.
+}
+
+// source returns the counters of the source with the given name.
+func (c *statsCollector) source(name string) *sourceCounters {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	counters, ok := c.sources[name]
+	if !ok {
+		counters = &sourceCounters{name: name, metrics: c.metrics, names: map[string]bool{}, errors: map[string]int{}}
+		c.sources[name] = counters
+	}
+	return counters
+}
+
+// record counts a result of the source with the given counters.
+func (c *statsCollector) record(counters *sourceCounters, result *Result) {
+	if c.metrics != nil {
+		c.metrics.Record(counters.name, result)
+	}
+
+	if result.IsFailure() {
+		counters.mutex.Lock()
+		counters.errors[ErrorKind(result.GetFailure())]++
+		counters.mutex.Unlock()
+		return
+	}
+	if !result.IsSuccess() {
+		return
+	}
+
+	name := NormalizeName(fmt.Sprint(result.GetSuccess()))
+
+	counters.mutex.Lock()
+	counters.results++
+	found := counters.names[name]
+	if found {
+		counters.duplicates++
+	} else {
+		counters.names[name] = true
+	}
+	counters.mutex.Unlock()
+
+	if !found {
+		c.mutex.Lock()
+		c.found[name]++
+		c.mutex.Unlock()
+	}
+}
+
+// stats returns the statistics of the named sources, followed by any others.
+func (c *statsCollector) stats(names []string) *Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := &Stats{Names: len(c.found), Sources: []*SourceStats{}}
+	listed := map[string]bool{}
+
+	add := func(name string, counters *sourceCounters) {
+		listed[name] = true
+		source := &SourceStats{Source: name, State: SourceQueued, Errors: map[string]int{}}
+		stats.Sources = append(stats.Sources, source)
+		if counters == nil {
+			return
+		}
+
+		counters.mutex.Lock()
+		defer counters.mutex.Unlock()
+		source.State = counters.state()
+		source.Requests = counters.requests
+		source.Bytes = counters.bytes
+		source.Results = counters.results
+		source.Unique = len(counters.names)
+		source.Duplicates = counters.duplicates
+		source.Time = counters.time
+		source.Waited = counters.waited
+		if counters.requests > 0 {
+			source.Latency = counters.latency / time.Duration(counters.requests)
+		}
+		for kind, count := range counters.errors {
+			source.Errors[kind] = count
+		}
+		for name := range counters.names {
+			if c.found[name] == 1 {
+				source.Exclusive++
+			}
+		}
+	}
+
+	for _, name := range names {
+		if !listed[name] {
+			add(name, c.sources[name])
+		}
+	}
+	for name, counters := range c.sources {
+		if !listed[name] {
+			add(name, counters)
+		}
+	}
+
+	return stats
+}
+
+// CountRequests returns an http.RoundTripper counting the requests made with
+// the given one, and the bytes of their responses, in the Stats of the source
+// making them, and logging them to the Logger of their context. HTTPClient
+// already counts them, so this is only needed by sources using their own clients.
+func CountRequests(transport http.RoundTripper) http.RoundTripper {
+	return &statsTransport{RoundTripper: transport}
+}
+
+// statsTransport counts and logs requests for the source in their context.
+type statsTransport struct {
+	http.RoundTripper
+}
+
+// RoundTrip makes the request, counting and logging it for the source in its context, if any.
+func (t *statsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := Logger(req.Context())
+	counters, ok := req.Context().Value(sourceStatsKey{}).(*sourceCounters)
+
+	start := time.Now()
+	resp, err := t.RoundTripper.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		logger.Debug("request failed", "method", req.Method, "url", RedactURL(req.URL.String()), "duration", elapsed, "error", RedactError(err))
+		if ok {
+			counters.request(0, elapsed)
+		}
+		return resp, err
+	}
+	logger.Debug("request", "method", req.Method, "url", RedactURL(req.URL.String()), "status", resp.StatusCode, "duration", elapsed)
+	logger.Log(req.Context(), LevelTrace, "headers", "url", RedactURL(req.URL.String()), "request", RedactHeader(req.Header), "response", RedactHeader(resp.Header))
+	if !ok {
+		return resp, nil
+	}
+	counters.request(resp.StatusCode, elapsed)
+
+	resp.Body = &countingBody{ReadCloser: resp.Body, counters: counters}
+	return resp, nil
+}
+
+// countingBody counts the bytes read from a response body.
+type countingBody struct {
+	io.ReadCloser
+	counters *sourceCounters
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.counters.read(n)
+	return n, err
+}
+
+// RecordWait counts the time the source in the context waited for its rate
+// limit in its Stats, for sources limiting how often they're queried.
+func RecordWait(ctx context.Context, wait time.Duration) {
+	if counters, ok := ctx.Value(sourceStatsKey{}).(*sourceCounters); ok {
+		counters.wait(wait)
+	}
+}
+
+// withSourceStats returns a context in which requests are counted with the given counters.
+func withSourceStats(ctx context.Context, counters *sourceCounters) context.Context {
+	return context.WithValue(ctx, sourceStatsKey{}, counters)
+}
+
+// sourceName returns the name of the source EnumerateSubdomains gave the context, if any.
+func sourceName(ctx context.Context) (string, bool) {
+	counters, ok := ctx.Value(sourceStatsKey{}).(*sourceCounters)
+	if !ok {
+		return "", false
+	}
+	return counters.name, true
+}
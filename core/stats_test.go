@@ -0,0 +1,125 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// httpSource finds the names listed by a server, one per line, twice.
+type httpSource struct {
+	URL string
+}
+
+func (s *httpSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+	go func() {
+		defer close(results)
+		req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+		if err != nil {
+			results <- NewResult("http", nil, err)
+			return
+		}
+		resp, err := HTTPClient.Do(req.WithContext(ctx))
+		if err != nil {
+			results <- NewResult("http", nil, err)
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		for i := 0; i < 2; i++ {
+			for _, name := range strings.Fields(string(body)) {
+				results <- NewResult("http", name, nil)
+			}
+		}
+		results <- NewResult("http", nil, errors.New("503 Service Unavailable"))
+	}()
+	return results
+}
+
+// emptySource never finds anything.
+type emptySource struct{}
+
+func (s *emptySource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+	close(results)
+	return results
+}
+
+func TestEnumerationOptions_Stats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "www.example.com\napi.example.com\n")
+	}))
+	defer server.Close()
+
+	options := &EnumerationOptions{Sources: []Source{&countingSource{}, &httpSource{URL: server.URL}, &emptySource{}}}
+	for range EnumerateSubdomains(context.Background(), "example.com", options) {
+	}
+
+	stats := options.Stats()
+	if stats.Names != 3 || len(stats.Sources) != 3 {
+		t.Fatalf("expected 3 names from 3 sources, got %d from %d", stats.Names, len(stats.Sources))
+	}
+
+	counting, http, empty := stats.Sources[0], stats.Sources[1], stats.Sources[2]
+	if counting.State != SourceDone || http.State != SourceFailed || empty.State != SourceDone {
+		t.Fatalf("expected the sources to be done, with the http source failed, got %v, %v and %v", counting.State, http.State, empty.State)
+	}
+	if counting.Source != "countingSource" || counting.Results != 2 || counting.Unique != 2 || counting.Exclusive != 1 || counting.Requests != 0 {
+		t.Fatalf("unexpected stats of the counting source: %+v", counting)
+	}
+	if http.Source != "httpSource" || http.Results != 4 || http.Unique != 2 || http.Duplicates != 2 || http.Exclusive != 1 {
+		t.Fatalf("unexpected stats of the http source: %+v", http)
+	}
+	if http.Requests != 1 || http.Bytes != 32 || http.Latency <= 0 || http.Errors[ErrorHTTPStatus] != 1 || http.ErrorCount() != 1 {
+		t.Fatalf("unexpected request stats of the http source: %+v", http)
+	}
+	if empty.Source != "emptySource" || empty.Results != 0 {
+		t.Fatalf("unexpected stats of the empty source: %+v", empty)
+	}
+
+	data, err := json.Marshal(http)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"requests":1,`) || !strings.Contains(string(data), `"latency_ms":`) {
+		t.Fatalf("unexpected JSON: %s", data)
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	stats := &Stats{Names: 2, Sources: []*SourceStats{
+		{Source: "CrtSh", Requests: 1, Results: 2, Unique: 2, Errors: map[string]int{}},
+		{Source: "Bing", Requests: 3, Errors: map[string]int{ErrorTimeout: 1, ErrorHTTPStatus: 2}},
+	}}
+
+	table := &strings.Builder{}
+	if err := WriteStats(table, StatsTable, stats); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(table.String(), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[0], "SOURCE ") || !strings.Contains(lines[2], "3 (http_status 2, timeout 1)") || lines[3] != "2 distinct names from 2 sources" {
+		t.Fatalf("unexpected table:\n%v", table)
+	}
+
+	data := &strings.Builder{}
+	if err := WriteStats(data, OutputJSON, stats); err != nil {
+		t.Fatal(err)
+	}
+	decoded := &struct {
+		Names   int
+		Sources []map[string]interface{}
+	}{}
+	if err := json.Unmarshal([]byte(data.String()), decoded); err != nil || decoded.Names != 2 || decoded.Sources[1]["source"] != "Bing" {
+		t.Fatalf("unexpected JSON: %v, %v", data, err)
+	}
+
+	if err := WriteStats(data, "xml", stats); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
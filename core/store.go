@@ -0,0 +1,307 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"golang.org/x/sync/semaphore"
+)
+
+// storeBucket is the top level bucket, which holds a bucket of
+// StoredSubdomains keyed by name for every root domain.
+var storeBucket = []byte("subdomains")
+
+// Resolution is an entry in the resolution history of a StoredSubdomain.
+type Resolution struct {
+	Time      time.Time `json:"time"`                // When the name was resolved to these addresses.
+	Addresses []string  `json:"addresses,omitempty"` // Sorted addresses, empty when the name didn't resolve.
+}
+
+// StoredSubdomain is everything a Store knows about a subdomain.
+type StoredSubdomain struct {
+	Name        string        `json:"name"`
+	Domain      string        `json:"domain"`      // The root domain it was found for.
+	Sources     []string      `json:"sources"`     // Every source that found it, sorted.
+	FirstSeen   time.Time     `json:"first_seen"`  // When it was first found.
+	LastSeen    time.Time     `json:"last_seen"`   // When it was last found.
+	Resolutions []*Resolution `json:"resolutions"` // Only has an entry whenever the addresses changed.
+}
+
+// Live checks if the most recent resolution had any addresses. The second
+// return value is false when the subdomain was never resolved at all.
+func (s *StoredSubdomain) Live() (bool, bool) {
+	if len(s.Resolutions) == 0 {
+		return false, false
+	}
+	return len(s.Resolutions[len(s.Resolutions)-1].Addresses) > 0, true
+}
+
+// StoreFilter narrows down what Store.Query returns, where zero values match anything.
+type StoreFilter struct {
+	Domain string    // Only subdomains of this root domain.
+	Source string    // Only subdomains found by this source.
+	Since  time.Time // Only subdomains last seen at or after this time.
+	Until  time.Time // Only subdomains first seen at or before this time.
+	Live   *bool     // Only subdomains whose last resolution did, or didn't, have addresses.
+}
+
+func (f *StoreFilter) matches(s *StoredSubdomain) bool {
+	if f.Source != "" {
+		found := false
+		for _, source := range s.Sources {
+			if source == f.Source {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && s.LastSeen.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && s.FirstSeen.After(f.Until) {
+		return false
+	}
+	if f.Live != nil {
+		live, resolved := s.Live()
+		if !resolved || live != *f.Live {
+			return false
+		}
+	}
+	return true
+}
+
+// StoreResolver is the subset of net.Resolver used by a Store.
+type StoreResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Store keeps every subdomain found for each root domain in a bbolt database file,
+// so findings can be compared and queried across runs.
+type Store struct {
+	Resolver    StoreResolver // Defaults to net.DefaultResolver.
+	Concurrency int           // Number of names resolved at once, defaults to 10.
+	Timeout     time.Duration // Timeout for each resolution, defaults to 5 seconds.
+	db          *bolt.DB
+	lock        *semaphore.Weighted
+	once        sync.Once
+}
+
+// OpenStore opens the Store in the given file, creating it if it doesn't exist yet.
+func OpenStore(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(storeBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) init() {
+	s.once.Do(func() {
+		if s.Resolver == nil {
+			s.Resolver = net.DefaultResolver
+		}
+		if s.Concurrency <= 0 {
+			s.Concurrency = 10
+		}
+		if s.Timeout <= 0 {
+			s.Timeout = 5 * time.Second
+		}
+		s.lock = semaphore.NewWeighted(int64(s.Concurrency))
+	})
+}
+
+// Close closes the underlying database file.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// NormalizeName lower cases the given name and strips any trailing dot, so
+// names from different sources and runs can be compared.
+func NormalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+}
+
+// Record stores a subdomain found by the given source for the given root domain at the
+// given time, along with its resolved addresses. A nil addresses slice means the name
+// wasn't resolved, which leaves the resolution history as is. Concurrent calls are
+// grouped into a single write transaction.
+func (s *Store) Record(domain, name, source string, seen time.Time, addresses []string) error {
+	domain = NormalizeName(domain)
+	name = NormalizeName(name)
+	if domain == "" || name == "" {
+		return errors.New("no domain or name to record")
+	}
+
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(storeBucket).CreateBucketIfNotExists([]byte(domain))
+		if err != nil {
+			return err
+		}
+
+		stored := &StoredSubdomain{Name: name, Domain: domain, FirstSeen: seen, LastSeen: seen}
+		if data := bucket.Get([]byte(name)); data != nil {
+			if err := json.Unmarshal(data, stored); err != nil {
+				return err
+			}
+		}
+
+		if seen.Before(stored.FirstSeen) {
+			stored.FirstSeen = seen
+		}
+		if seen.After(stored.LastSeen) {
+			stored.LastSeen = seen
+		}
+
+		if source != "" {
+			i := sort.SearchStrings(stored.Sources, source)
+			if i == len(stored.Sources) || stored.Sources[i] != source {
+				stored.Sources = append(stored.Sources, "")
+				copy(stored.Sources[i+1:], stored.Sources[i:])
+				stored.Sources[i] = source
+			}
+		}
+
+		if addresses != nil {
+			sorted := append([]string{}, addresses...)
+			sort.Strings(sorted)
+			last := len(stored.Resolutions) - 1
+			if last < 0 || strings.Join(stored.Resolutions[last].Addresses, ",") != strings.Join(sorted, ",") {
+				stored.Resolutions = append(stored.Resolutions, &Resolution{Time: seen, Addresses: sorted})
+			}
+		}
+
+		data, err := json.Marshal(stored)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(name), data)
+	})
+}
+
+// RecordResults resolves and records every successful Result from the input channel
+// with a root domain set, passing each one along to the returned output channel. Each
+// name is only resolved once per call, and related domains aren't recorded at all. A
+// Result which couldn't be recorded is still passed along as it is, with the error
+// logged to the Logger of the context. Once the context is done the remaining names
+// are still recorded, only without resolving them, as are all names while proxies
+// are given to SetProxies, so no DNS queries leave this host.
+func (s *Store) RecordResults(ctx context.Context, input <-chan *Result) <-chan *Result {
+	s.init()
+
+	resolved := map[string][]string{}
+	mutex := sync.Mutex{}
+
+	return annotateResults(context.WithoutCancel(ctx), input, s.lock, func(_ context.Context, result *Result, name string) {
+		domain := result.GetDomain()
+		if domain == "" {
+			return
+		}
+
+		name = NormalizeName(name)
+
+		mutex.Lock()
+		addresses, ok := resolved[name]
+		mutex.Unlock()
+
+		if !ok && ctx.Err() == nil && !proxied() {
+			lookupCtx, cancel := context.WithTimeout(ctx, s.Timeout)
+			var err error
+			addresses, err = s.Resolver.LookupHost(lookupCtx, name)
+			cancel()
+			if err != nil && !isNotFound(err) {
+				// a failed lookup says nothing about the name
+				addresses = nil
+			} else if addresses == nil {
+				addresses = []string{}
+			}
+			mutex.Lock()
+			resolved[name] = addresses
+			mutex.Unlock()
+		}
+
+		seen := result.GetTimestamp()
+		if seen.IsZero() {
+			seen = time.Now().UTC()
+		}
+
+		if err := s.Record(domain, name, result.GetType(), seen, addresses); err != nil {
+			Logger(ctx).Warn("recording failed", "domain", domain, "name", name, "error", err)
+		}
+	})
+}
+
+// Known checks if the given subdomain was already recorded for the given root domain.
+func (s *Store) Known(domain, name string) (bool, error) {
+	known := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket(storeBucket).Bucket([]byte(NormalizeName(domain))); bucket != nil {
+			known = bucket.Get([]byte(NormalizeName(name))) != nil
+		}
+		return nil
+	})
+	return known, err
+}
+
+// HasDomain checks if anything was recorded for the given root domain yet.
+func (s *Store) HasDomain(domain string) (bool, error) {
+	has := false
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(storeBucket).Bucket([]byte(NormalizeName(domain)))
+		if bucket != nil {
+			key, _ := bucket.Cursor().First()
+			has = key != nil
+		}
+		return nil
+	})
+	return has, err
+}
+
+// Query returns every StoredSubdomain matching the given filter, sorted by root domain and name.
+func (s *Store) Query(filter *StoreFilter) ([]*StoredSubdomain, error) {
+	if filter == nil {
+		filter = &StoreFilter{}
+	}
+
+	found := []*StoredSubdomain{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(storeBucket).ForEach(func(domain, _ []byte) error {
+			if filter.Domain != "" && string(domain) != NormalizeName(filter.Domain) {
+				return nil
+			}
+			// keys are kept in byte order, so names come out sorted
+			return tx.Bucket(storeBucket).Bucket(domain).ForEach(func(_, data []byte) error {
+				stored := &StoredSubdomain{}
+				if err := json.Unmarshal(data, stored); err != nil {
+					return err
+				}
+				if filter.matches(stored) {
+					found = append(found, stored)
+				}
+				return nil
+			})
+		})
+	})
+
+	return found, err
+}
@@ -0,0 +1,196 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeStoreResolver map[string][]string
+
+func (r fakeStoreResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addresses, ok := r[host]; ok {
+		return addresses, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func openTestStore(t *testing.T) *Store {
+	store, err := OpenStore(filepath.Join(t.TempDir(), "subzero.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore_Record(t *testing.T) {
+	store := openTestStore(t)
+
+	day1 := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	day3 := day1.AddDate(0, 0, 2)
+
+	records := []struct {
+		name      string
+		source    string
+		seen      time.Time
+		addresses []string
+	}{
+		{"WWW.example.com.", "crtsh", day1, []string{"10.0.0.2", "10.0.0.1"}},
+		{"www.example.com", "virustotal", day2, []string{"10.0.0.1", "10.0.0.2"}},
+		{"www.example.com", "crtsh", day3, []string{}},
+		{"old.example.com", "bing", day1, []string{}},
+		{"new.example.com", "bing", day3, nil},
+	}
+
+	for _, record := range records {
+		if err := store.Record("example.com", record.name, record.source, record.seen, record.addresses); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := store.Query(&StoreFilter{Domain: "Example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 3 {
+		t.Fatalf("expected 3 subdomains, got %d", len(found))
+	}
+
+	www := found[2]
+	if www.Name != "www.example.com" || !www.FirstSeen.Equal(day1) || !www.LastSeen.Equal(day3) {
+		t.Fatalf("expected www.example.com seen from '%v' to '%v', got '%+v'", day1, day3, www)
+	}
+	if len(www.Sources) != 2 || www.Sources[0] != "crtsh" || www.Sources[1] != "virustotal" {
+		t.Fatalf("expected '[crtsh virustotal]', got '%v'", www.Sources)
+	}
+	// the second resolution didn't change anything
+	if len(www.Resolutions) != 2 || len(www.Resolutions[0].Addresses) != 2 || len(www.Resolutions[1].Addresses) != 0 {
+		t.Fatalf("expected 2 resolutions, got '%+v'", www.Resolutions)
+	}
+
+	live := false
+	var units = []struct {
+		filter *StoreFilter
+		exp    int
+	}{
+		{&StoreFilter{}, 3},
+		{&StoreFilter{Domain: "example.org"}, 0},
+		{&StoreFilter{Source: "bing"}, 2},
+		{&StoreFilter{Since: day2}, 2},
+		{&StoreFilter{Until: day1}, 2},
+		{&StoreFilter{Since: day2, Until: day2}, 1},
+		{&StoreFilter{Live: &live}, 2},
+	}
+	for _, u := range units {
+		found, err := store.Query(u.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != u.exp {
+			t.Fatalf("expected %d subdomains for '%+v', got %d", u.exp, u.filter, len(found))
+		}
+	}
+}
+
+func TestStore_RecordResults(t *testing.T) {
+	store := openTestStore(t)
+	store.Resolver = fakeStoreResolver{"a.example.com": {"10.0.0.1"}}
+
+	input := make(chan *Result)
+	go func() {
+		defer close(input)
+		for _, name := range []string{"a.example.com", "b.example.com"} {
+			result := NewResult("example", name, nil)
+			result.SetDomain("example.com")
+			input <- result
+		}
+		related := NewResult("example", RelatedDomain("example.org"), nil)
+		related.SetDomain("example.com")
+		input <- related
+	}()
+
+	count := 0
+	for result := range store.RecordResults(context.Background(), input) {
+		if result.IsFailure() {
+			t.Fatal(result.Failure)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Fatalf("expected 3 results to be passed along, got %d", count)
+	}
+
+	live := true
+	found, err := store.Query(&StoreFilter{Live: &live})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Name != "a.example.com" {
+		t.Fatalf("expected only a.example.com to be live, got '%v'", found)
+	}
+
+	found, _ = store.Query(nil)
+	if len(found) != 2 {
+		t.Fatalf("expected related domains to not be recorded, got %d subdomains", len(found))
+	}
+}
+
+func TestStore_RecordResults_Proxied(t *testing.T) {
+	store := openTestStore(t)
+	store.Resolver = fakeStoreResolver{"a.example.com": {"10.0.0.1"}}
+
+	proxies, err := NewProxies([]string{"socks5://127.0.0.1:1080"}, ProxyRoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProxies(proxies)
+	defer SetProxies(nil)
+
+	input := make(chan *Result, 1)
+	result := NewResult("example", "a.example.com", nil)
+	result.SetDomain("example.com")
+	input <- result
+	close(input)
+
+	for range store.RecordResults(context.Background(), input) {
+	}
+
+	// recorded, but not resolved through the local resolver
+	found, _ := store.Query(nil)
+	live := true
+	resolved, _ := store.Query(&StoreFilter{Live: &live})
+	if len(found) != 1 || len(resolved) != 0 {
+		t.Fatalf("expected a.example.com to be recorded without resolving it, got '%v' and '%v'", found, resolved)
+	}
+}
+
+func TestStore_RecordResults_KeepsFailedRecords(t *testing.T) {
+	store := openTestStore(t)
+	store.Resolver = fakeStoreResolver{}
+	store.Close()
+
+	output := &bytes.Buffer{}
+	logger, _ := NewLogger(output, LogText, slog.LevelWarn)
+
+	input := make(chan *Result, 1)
+	result := NewResult("example", "a.example.com", nil)
+	result.SetDomain("example.com")
+	input <- result
+	close(input)
+
+	for result := range store.RecordResults(WithLogger(context.Background(), logger), input) {
+		if !result.IsSuccess() {
+			t.Fatalf("expected the finding to be passed along as it is, got '%v'", result.GetFailure())
+		}
+	}
+	if !strings.Contains(output.String(), "msg=\"recording failed\" domain=example.com name=a.example.com") {
+		t.Fatalf("expected the error to be logged, got '%v'", output)
+	}
+}
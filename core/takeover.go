@@ -0,0 +1,209 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// maxCNAMEChain limits how many CNAME hops will be followed for a single name.
+const maxCNAMEChain = 10
+
+// maxTakeoverBodySize limits how much of an HTTP response body is searched for a fingerprint.
+const maxTakeoverBodySize = 1 << 20
+
+// TakeoverResolver does the DNS lookups of the TakeoverChecker, which allows them
+// to be swapped out. LookupCNAME returns the next hop of a chain, the target of
+// the name's own CNAME record, like CNAMEResolver does, so the whole chain is
+// followed; with the canonical name net.Resolver returns, only the final target
+// of a chain is fingerprinted, and dangling ones aren't found.
+type TakeoverResolver interface {
+	LookupCNAME(ctx context.Context, host string) (string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Takeover contains the outcome of a subdomain takeover check for a Result.
+type Takeover struct {
+	Service    string   `json:"service"`    // The service whose fingerprint matched.
+	CNAMEs     []string `json:"cnames"`     // The CNAME chain that was followed.
+	Target     string   `json:"target"`     // The last CNAME target in the chain.
+	Vulnerable bool     `json:"vulnerable"` // Whether the takeover was confirmed.
+	Reason     string   `json:"reason"`     // Why the Result was (or wasn't) flagged.
+}
+
+// String returns a human readable description of the Takeover.
+func (t *Takeover) String() string {
+	if t.Vulnerable {
+		return fmt.Sprintf("vulnerable to %v takeover: %v", t.Service, t.Reason)
+	}
+	return fmt.Sprintf("possible %v takeover: %v", t.Service, t.Reason)
+}
+
+// TakeoverChecker follows the CNAME chain of found subdomains and matches the
+// targets against a fingerprint database to find dangling records.
+type TakeoverChecker struct {
+	Fingerprints []*TakeoverFingerprint // Defaults to DefaultTakeoverFingerprints.
+	Resolver     TakeoverResolver       // Defaults to a CNAMEResolver.
+	HTTPClient   *http.Client           // Defaults to HTTPClient.
+	Concurrency  int                    // Number of names checked at once, defaults to 10.
+	lock         *semaphore.Weighted
+	once         sync.Once
+}
+
+func (c *TakeoverChecker) init() {
+	c.once.Do(func() {
+		if c.Fingerprints == nil {
+			c.Fingerprints = DefaultTakeoverFingerprints
+		}
+		if c.Resolver == nil {
+			c.Resolver = &CNAMEResolver{}
+		}
+		if c.HTTPClient == nil {
+			c.HTTPClient = HTTPClient
+		}
+		if c.Concurrency <= 0 {
+			c.Concurrency = 10
+		}
+		c.lock = semaphore.NewWeighted(int64(c.Concurrency))
+	})
+}
+
+// isNotFound checks if the given error is a DNS NXDOMAIN response.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsNotFound
+	}
+	return false
+}
+
+// cnameChain follows the CNAME records for the given name, returning each target in order.
+func (c *TakeoverChecker) cnameChain(ctx context.Context, name string) ([]string, error) {
+	chain := []string{}
+	current := name
+	for i := 0; i < maxCNAMEChain; i++ {
+		cname, err := c.Resolver.LookupCNAME(ctx, current)
+		if err != nil {
+			// a dangling chain ends in a name which doesn't exist
+			if len(chain) > 0 && isNotFound(err) {
+				break
+			}
+			return nil, err
+		}
+		cname = strings.ToLower(strings.TrimSuffix(cname, "."))
+		if cname == "" || cname == current {
+			break
+		}
+		chain = append(chain, cname)
+		current = cname
+	}
+	return chain, nil
+}
+
+// fetchBody retrieves the start of the HTTP response body served for the given name.
+func (c *TakeoverChecker) fetchBody(ctx context.Context, name string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, "http://"+name+"/", nil)
+	if err != nil {
+		return "", err
+	}
+
+	req = req.WithContext(ctx)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTakeoverBodySize))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// Check looks for a subdomain takeover on the given name. A nil Takeover is
+// returned if the name's CNAME chain doesn't match any known service.
+func (c *TakeoverChecker) Check(ctx context.Context, name string) (*Takeover, error) {
+	c.init()
+
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+
+	chain, err := c.cnameChain(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	var fingerprint *TakeoverFingerprint
+	for _, fp := range c.Fingerprints {
+		for _, cname := range chain {
+			if fp.MatchesCNAME(cname) {
+				fingerprint = fp
+				break
+			}
+		}
+		if fingerprint != nil {
+			break
+		}
+	}
+
+	if fingerprint == nil {
+		return nil, nil
+	}
+
+	takeover := &Takeover{
+		Service: fingerprint.Service,
+		CNAMEs:  chain,
+		Target:  chain[len(chain)-1],
+	}
+
+	if fingerprint.NXDomain {
+		_, err := c.Resolver.LookupHost(ctx, takeover.Target)
+		if isNotFound(err) {
+			takeover.Vulnerable = true
+			takeover.Reason = fmt.Sprintf("CNAME target %v returned NXDOMAIN", takeover.Target)
+			return takeover, nil
+		}
+	}
+
+	if fingerprint.Fingerprint != "" {
+		body, err := c.fetchBody(ctx, name)
+		if err == nil && strings.Contains(body, fingerprint.Fingerprint) {
+			takeover.Vulnerable = true
+			takeover.Reason = fmt.Sprintf("CNAME target %v and HTTP response matched %q", takeover.Target, fingerprint.Fingerprint)
+			return takeover, nil
+		}
+	}
+
+	takeover.Reason = fmt.Sprintf("CNAME target %v matched, but the takeover could not be confirmed", takeover.Target)
+
+	return takeover, nil
+}
+
+// CheckResults runs a takeover check for every successful Result from the given
+// input channel, attaching any matches to the Result before sending it down the
+// returned output channel. Failed results are passed along untouched.
+func (c *TakeoverChecker) CheckResults(ctx context.Context, input <-chan *Result) <-chan *Result {
+	c.init()
+
+	return annotateResults(ctx, input, c.lock, func(ctx context.Context, result *Result, name string) {
+		takeover, err := c.Check(ctx, name)
+		if err == nil && takeover != nil {
+			result.SetTakeover(takeover)
+		}
+	})
+}
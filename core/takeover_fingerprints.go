@@ -0,0 +1,125 @@
+package core
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// TakeoverFingerprint describes how a third-party service looks when a
+// subdomain still points at it, but the resource behind it has been
+// deprovisioned and could be claimed by someone else.
+type TakeoverFingerprint struct {
+	Service     string   `json:"service"`               // Name of the service, like "GitHub Pages".
+	CNAMEs      []string `json:"cname"`                 // Substrings which identify the service in a CNAME target.
+	Fingerprint string   `json:"fingerprint,omitempty"` // HTTP body signature of an unclaimed resource.
+	NXDomain    bool     `json:"nxdomain,omitempty"`    // Whether an NXDOMAIN for the CNAME target confirms the takeover.
+}
+
+// MatchesCNAME checks if the given CNAME target belongs to the service.
+func (fp *TakeoverFingerprint) MatchesCNAME(target string) bool {
+	target = strings.ToLower(strings.TrimSuffix(target, "."))
+	for _, cname := range fp.CNAMEs {
+		if strings.Contains(target, strings.ToLower(cname)) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTakeoverFingerprints reads a JSON array of fingerprints from the given reader,
+// which allows the built-in DefaultTakeoverFingerprints to be updated without
+// recompiling.
+func LoadTakeoverFingerprints(r io.Reader) ([]*TakeoverFingerprint, error) {
+	fingerprints := []*TakeoverFingerprint{}
+	err := json.NewDecoder(r).Decode(&fingerprints)
+	if err != nil {
+		return nil, err
+	}
+	return fingerprints, nil
+}
+
+// DefaultTakeoverFingerprints is the built-in fingerprint database used when a
+// TakeoverChecker isn't given any fingerprints of its own.
+var DefaultTakeoverFingerprints = []*TakeoverFingerprint{
+	{
+		Service:     "AWS/S3",
+		CNAMEs:      []string{".s3.amazonaws.com", ".s3-website", ".s3.dualstack."},
+		Fingerprint: "The specified bucket does not exist",
+	},
+	{
+		Service:  "AWS/Elastic Beanstalk",
+		CNAMEs:   []string{".elasticbeanstalk.com"},
+		NXDomain: true,
+	},
+	{
+		Service:  "Azure",
+		CNAMEs:   []string{".azurewebsites.net", ".cloudapp.net", ".cloudapp.azure.com", ".trafficmanager.net", ".blob.core.windows.net", ".azureedge.net", ".azure-api.net", ".azurecontainer.io"},
+		NXDomain: true,
+	},
+	{
+		Service:     "Bitbucket",
+		CNAMEs:      []string{".bitbucket.io"},
+		Fingerprint: "Repository not found",
+	},
+	{
+		Service:     "Fastly",
+		CNAMEs:      []string{".fastly.net"},
+		Fingerprint: "Fastly error: unknown domain",
+	},
+	{
+		Service:     "Ghost",
+		CNAMEs:      []string{".ghost.io"},
+		Fingerprint: "The thing you were looking for is no longer here, or never was",
+	},
+	{
+		Service:     "GitHub Pages",
+		CNAMEs:      []string{".github.io"},
+		Fingerprint: "There isn't a GitHub Pages site here.",
+	},
+	{
+		Service:     "Heroku",
+		CNAMEs:      []string{".herokuapp.com", ".herokudns.com", ".herokussl.com"},
+		Fingerprint: "No such app",
+	},
+	{
+		Service:     "Pantheon",
+		CNAMEs:      []string{".pantheonsite.io"},
+		Fingerprint: "The gods are wise, but do not know of the site which you seek.",
+	},
+	{
+		Service:     "Readme.io",
+		CNAMEs:      []string{".readme.io"},
+		Fingerprint: "Project doesnt exist... yet!",
+	},
+	{
+		Service:     "Shopify",
+		CNAMEs:      []string{".myshopify.com"},
+		Fingerprint: "Sorry, this shop is currently unavailable.",
+	},
+	{
+		Service:     "Surge.sh",
+		CNAMEs:      []string{".surge.sh"},
+		Fingerprint: "project not found",
+	},
+	{
+		Service:     "Tumblr",
+		CNAMEs:      []string{"domains.tumblr.com"},
+		Fingerprint: "Whatever you were looking for doesn't currently exist at this address.",
+	},
+	{
+		Service:     "Unbounce",
+		CNAMEs:      []string{".unbouncepages.com"},
+		Fingerprint: "The requested URL was not found on this server.",
+	},
+	{
+		Service:     "WordPress",
+		CNAMEs:      []string{".wordpress.com"},
+		Fingerprint: "Do you want to register",
+	},
+	{
+		Service:     "Zendesk",
+		CNAMEs:      []string{".zendesk.com"},
+		Fingerprint: "Help Center Closed",
+	},
+}
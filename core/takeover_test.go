@@ -0,0 +1,173 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTakeoverResolver stands in for DNS, mapping names to CNAME targets and addresses.
+// Like CNAMEResolver, LookupCNAME returns one hop of a chain at a time.
+type fakeTakeoverResolver struct {
+	cnames map[string]string
+	hosts  map[string][]string
+}
+
+func (r *fakeTakeoverResolver) LookupCNAME(ctx context.Context, host string) (string, error) {
+	if cname, ok := r.cnames[host]; ok {
+		return cname + ".", nil
+	}
+	if _, ok := r.hosts[host]; ok {
+		return host + ".", nil
+	}
+	return "", &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func (r *fakeTakeoverResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+// newTakeoverTestClient returns an HTTP client which sends every request to the given server.
+func newTakeoverTestClient(server *httptest.Server) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+			},
+		},
+	}
+}
+
+func TestTakeoverChecker_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Host {
+		case "docs.example.com":
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, "<h1>There isn't a GitHub Pages site here.</h1>")
+		default:
+			fmt.Fprintln(w, "<h1>Welcome</h1>")
+		}
+	}))
+	defer server.Close()
+
+	checker := &TakeoverChecker{
+		Resolver: &fakeTakeoverResolver{
+			cnames: map[string]string{
+				"docs.example.com":   "example.github.io",
+				"blog.example.com":   "claimed.github.io",
+				"app.example.com":    "edge.example.net",
+				"edge.example.net":   "gone.azurewebsites.net",
+				"static.example.com": "cdn.example.net",
+			},
+			hosts: map[string][]string{
+				"example.github.io": {"127.0.0.1"},
+				"claimed.github.io": {"127.0.0.1"},
+				"cdn.example.net":   {"127.0.0.1"},
+				"www.example.com":   {"127.0.0.1"},
+			},
+		},
+		HTTPClient: newTakeoverTestClient(server),
+	}
+
+	var units = []struct {
+		name       string
+		service    string
+		vulnerable bool
+	}{
+		{"docs.example.com", "GitHub Pages", true},
+		{"blog.example.com", "GitHub Pages", false},
+		{"app.example.com", "Azure", true},
+		{"static.example.com", "", false},
+		{"www.example.com", "", false},
+		{"missing.example.com", "", false},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, u := range units {
+		takeover, err := checker.Check(ctx, u.name)
+		if err != nil {
+			t.Fatalf("expected no error for '%v', got '%v'", u.name, err)
+		}
+		if u.service == "" {
+			if takeover != nil {
+				t.Fatalf("expected no takeover for '%v', got '%v'", u.name, takeover)
+			}
+			continue
+		}
+		if takeover == nil {
+			t.Fatalf("expected '%v' takeover for '%v', got none", u.service, u.name)
+		}
+		if takeover.Service != u.service {
+			t.Fatalf("expected '%v', got '%v'", u.service, takeover.Service)
+		}
+		if takeover.Vulnerable != u.vulnerable {
+			t.Fatalf("expected '%v' for '%v', got '%v'", u.vulnerable, u.name, takeover)
+		}
+	}
+}
+
+func TestTakeoverChecker_CheckResults(t *testing.T) {
+	checker := &TakeoverChecker{
+		Resolver: &fakeTakeoverResolver{
+			cnames: map[string]string{"old.example.com": "gone.elasticbeanstalk.com"},
+			hosts:  map[string][]string{"www.example.com": {"127.0.0.1"}},
+		},
+	}
+
+	input := make(chan *Result)
+	go func() {
+		defer close(input)
+		input <- NewResult("example", "old.example.com", nil)
+		input <- NewResult("example", "www.example.com", nil)
+		input <- NewResult("example", nil, fmt.Errorf("failed"))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	counter := 0
+	vulnerable := 0
+
+	for result := range checker.CheckResults(ctx, input) {
+		counter++
+		if takeover := result.GetTakeover(); takeover != nil && takeover.Vulnerable {
+			vulnerable++
+			if result.Success != "old.example.com" {
+				t.Fatalf("expected 'old.example.com', got '%v'", result.Success)
+			}
+		}
+	}
+
+	if counter != 3 {
+		t.Fatalf("expected '3' results, got '%v'", counter)
+	}
+
+	if vulnerable != 1 {
+		t.Fatalf("expected '1' vulnerable result, got '%v'", vulnerable)
+	}
+}
+
+func TestLoadTakeoverFingerprints(t *testing.T) {
+	fingerprints, err := LoadTakeoverFingerprints(strings.NewReader(`[{"service":"Example","cname":[".example-cloud.com"],"fingerprint":"no such site"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fingerprints) != 1 {
+		t.Fatalf("expected '1', got '%v'", len(fingerprints))
+	}
+
+	if !fingerprints[0].MatchesCNAME("Thing.Example-Cloud.com.") {
+		t.Fatalf("expected '%v' to match", fingerprints[0].CNAMEs)
+	}
+}
@@ -0,0 +1,305 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/sync/semaphore"
+)
+
+// TLSHarvestLabel is the type of the Results found by a TLSHarvester.
+const TLSHarvestLabel = "tlscertificates"
+
+// defaultTLSPorts are common ports which speak TLS right after connecting.
+var defaultTLSPorts = []int{443, 465, 636, 853, 993, 995, 8443}
+
+// TLSResolver is the subset of *net.Resolver used by a TLSHarvester.
+type TLSResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// TLSHarvester performs TLS handshakes against hosts and their IP addresses,
+// both with and without SNI, to find more names in the SANs and CN of the
+// certificates they serve. This picks up names from private CAs which never
+// reach any certificate transparency log.
+type TLSHarvester struct {
+	Ports       []int         // Defaults to common TLS ports.
+	Timeout     time.Duration // Timeout for each handshake, defaults to 5 seconds.
+	Concurrency int           // Number of ports harvested at once, defaults to 10.
+	Resolver    TLSResolver   // Defaults to net.DefaultResolver.
+	lock        *semaphore.Weighted
+	once        sync.Once
+}
+
+func (h *TLSHarvester) init() {
+	h.once.Do(func() {
+		if len(h.Ports) == 0 {
+			h.Ports = defaultTLSPorts
+		}
+		if h.Timeout <= 0 {
+			h.Timeout = 5 * time.Second
+		}
+		if h.Concurrency <= 0 {
+			h.Concurrency = 10
+		}
+		if h.Resolver == nil {
+			h.Resolver = net.DefaultResolver
+		}
+		h.lock = semaphore.NewWeighted(int64(h.Concurrency))
+	})
+}
+
+// handshake connects to the given address and returns the leaf certificate,
+// and whether the connection was made at all. No SNI is sent if serverName
+// is empty.
+func (h *TLSHarvester) handshake(ctx context.Context, address, serverName string) (*x509.Certificate, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	// through the SOCKS5 proxies given to SetProxies, if any
+	raw, err := DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, false, err
+	}
+	conn := tls.Client(raw, &tls.Config{
+		ServerName: serverName,
+		// the certificate is harvested, not trusted
+		InsecureSkipVerify: true,
+	})
+	defer conn.Close()
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return nil, true, err
+	}
+
+	state := conn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil, true, nil
+	}
+
+	return state.PeerCertificates[0], true, nil
+}
+
+// harvestPort returns the names in the certificates served on the given port
+// of the given addresses, once the lock is acquired.
+func (h *TLSHarvester) harvestPort(ctx context.Context, addrs, serverNames []string, port int) []string {
+	if err := h.lock.Acquire(ctx, 1); err != nil {
+		return nil
+	}
+	defer h.lock.Release(1)
+
+	names := []string{}
+	for _, addr := range addrs {
+		address := net.JoinHostPort(addr, strconv.Itoa(port))
+		for _, serverName := range serverNames {
+			if ctx.Err() != nil {
+				return names
+			}
+
+			cert, connected, err := h.handshake(ctx, address, serverName)
+			if !connected {
+				// nothing listens, so trying again without SNI would only wait as long
+				break
+			}
+			if err != nil || cert == nil {
+				continue
+			}
+
+			names = append(names, CertificateNames(cert)...)
+		}
+	}
+	return names
+}
+
+// Harvest returns the lower cased names, without any wildcard, in the
+// certificates served by the given host or IP address on any of the Ports.
+// Only a failure to resolve the host is returned as an error, as most hosts
+// won't be listening on most of the ports. Through the proxies given to
+// SetProxies, the host is resolved by the proxy instead, and its addresses
+// aren't harvested one by one. The ports are harvested at once, up to the
+// Concurrency shared by every harvest of the TLSHarvester.
+func (h *TLSHarvester) Harvest(ctx context.Context, host string) ([]string, error) {
+	h.init()
+
+	addrs := []string{host}
+	serverNames := []string{""}
+	if net.ParseIP(host) == nil {
+		if !proxied() {
+			var err error
+			addrs, err = h.Resolver.LookupHost(ctx, host)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// once with the host as SNI, and once without any
+		serverNames = []string{host, ""}
+	}
+
+	// kept by port, so the names come out in the same order every time
+	found := make([][]string, len(h.Ports))
+	wg := sync.WaitGroup{}
+	for i, port := range h.Ports {
+		wg.Add(1)
+		go func(i, port int) {
+			defer wg.Done()
+			found[i] = h.harvestPort(ctx, addrs, serverNames, port)
+		}(i, port)
+	}
+	wg.Wait()
+
+	names := []string{}
+	seen := map[string]bool{}
+	for _, portNames := range found {
+		for _, name := range portNames {
+			name = strings.ToLower(strings.TrimPrefix(name, "*."))
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// SplitHarvestedNames splits the names harvested for the given root domain into
+// the subdomains under it, and the other registrable domains, each only once.
+func SplitHarvestedNames(domain string, names []string) ([]string, []RelatedDomain) {
+	domainExtractor := NewSingleSubdomainExtractor(domain)
+	apex, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		apex = domain
+	}
+
+	subdomains := []string{}
+	related := []RelatedDomain{}
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		if str := domainExtractor([]byte(name)); str != "" {
+			if !seen[str] {
+				seen[str] = true
+				subdomains = append(subdomains, str)
+			}
+			continue
+		}
+
+		other, err := publicsuffix.EffectiveTLDPlusOne(name)
+		if err != nil || other == apex || seen["related:"+other] {
+			continue
+		}
+		seen["related:"+other] = true
+		related = append(related, RelatedDomain(other))
+	}
+
+	return subdomains, related
+}
+
+// HarvestResults passes along every Result from the given input channel, while
+// harvesting the certificates of the hosts of the successful ones. Names found
+// under the root domain of a Result which aren't in the stream yet are sent as
+// new Results, and harvested in turn, along with the related domains found.
+// Once the context is done harvesting stops, while the input is still passed
+// along, so nothing found is lost as long as the output is drained.
+func (h *TLSHarvester) HarvestResults(ctx context.Context, input <-chan *Result) <-chan *Result {
+	h.init()
+
+	output := make(chan *Result)
+
+	go func() {
+		defer close(output)
+
+		wg := sync.WaitGroup{}
+		mutex := sync.Mutex{}
+		seen := map[string]bool{}
+
+		send := func(result *Result) bool {
+			select {
+			case <-ctx.Done():
+				return false
+			case output <- result:
+				return true
+			}
+		}
+
+		// isNew records the given name as seen for the domain, returning whether it wasn't yet
+		isNew := func(domain, name string) bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+			key := domain + " " + name
+			if seen[key] {
+				return false
+			}
+			seen[key] = true
+			return true
+		}
+
+		// harvests the host in the background, and the new names found in turn,
+		// the handshakes being limited by the lock of Harvest
+		var harvest func(domain, host string)
+		harvest = func(domain, host string) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				names, err := h.Harvest(ctx, host)
+				if err != nil {
+					return
+				}
+
+				subdomains, related := SplitHarvestedNames(domain, names)
+				for _, name := range subdomains {
+					if !isNew(domain, name) {
+						continue
+					}
+					result := NewResult(TLSHarvestLabel, name, nil)
+					result.SetDomain(domain)
+					if !send(result) {
+						return
+					}
+					harvest(domain, name)
+				}
+				for _, name := range related {
+					if !isNew(domain, "related:"+string(name)) {
+						continue
+					}
+					result := NewResult(TLSHarvestLabel, name, nil)
+					result.SetDomain(domain)
+					if !send(result) {
+						return
+					}
+				}
+			}()
+		}
+
+		for result := range input {
+			output <- result
+
+			name, ok := result.GetSuccess().(string)
+			if !result.IsSuccess() || !ok || ctx.Err() != nil {
+				continue
+			}
+
+			name = NormalizeName(name)
+			domain := NormalizeName(result.GetDomain())
+			if domain == "" {
+				domain = name
+			}
+			if !isNew(domain, name) {
+				continue
+			}
+
+			harvest(domain, name)
+		}
+
+		wg.Wait()
+	}()
+
+	return output
+}
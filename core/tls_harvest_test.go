@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeTLSResolver map[string][]string
+
+func (r fakeTLSResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if addrs, ok := r[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func newTestTLSCertificate(t *testing.T, names ...string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: names[0]},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestTLSHarvester_HarvestResults(t *testing.T) {
+	// the certificate served depends on the SNI, so each host found leads to the next
+	certificates := map[string]tls.Certificate{
+		"www.example.com": newTestTLSCertificate(t, "www.example.com", "api.example.com"),
+		"api.example.com": newTestTLSCertificate(t, "*.deep.example.com"),
+		"":                newTestTLSCertificate(t, "www.example.com", "example.org"),
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		GetCertificate: func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			certificate, ok := certificates[hello.ServerName]
+			if !ok {
+				certificate = certificates[""]
+			}
+			return &certificate, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}(conn)
+		}
+	}()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+
+	harvester := &TLSHarvester{
+		Ports:       []int{p},
+		Concurrency: 1,
+		Resolver: fakeTLSResolver{
+			"www.example.com":  {"127.0.0.1"},
+			"api.example.com":  {"127.0.0.1"},
+			"deep.example.com": {"127.0.0.1"},
+		},
+	}
+
+	input := make(chan *Result, 2)
+	found := NewResult("crtsh", "www.example.com", nil)
+	found.SetDomain("example.com")
+	input <- found
+	input <- NewResult("crtsh", nil, context.DeadlineExceeded)
+	close(input)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	names := []string{}
+	related := []string{}
+	failures := 0
+	for result := range harvester.HarvestResults(ctx, input) {
+		switch success := result.GetSuccess().(type) {
+		case string:
+			names = append(names, result.GetType()+" "+success)
+		case RelatedDomain:
+			related = append(related, string(success))
+		default:
+			failures++
+		}
+		if result.IsSuccess() && result.GetDomain() != "example.com" {
+			t.Fatalf("expected the root domain to be set, got '%v'", result.GetDomain())
+		}
+	}
+	sort.Strings(names)
+
+	expected := []string{"crtsh www.example.com", "tlscertificates api.example.com", "tlscertificates deep.example.com"}
+	if len(names) != len(expected) || names[0] != expected[0] || names[1] != expected[1] || names[2] != expected[2] {
+		t.Fatalf("expected '%v', got '%v'", expected, names)
+	}
+	if len(related) != 1 || related[0] != "example.org" {
+		t.Fatalf("expected '[example.org]', got '%v'", related)
+	}
+	if failures != 1 {
+		t.Fatalf("expected the failure to be passed along, got %d", failures)
+	}
+}
+
+// newTestSOCKS5Proxy serves a SOCKS5 proxy without authentication, which connects
+// every CONNECT to the given target, recording the host asked for.
+func newTestSOCKS5Proxy(t *testing.T, target string) (string, func() []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	mutex := sync.Mutex{}
+	requested := []string{}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				buf := make([]byte, 256)
+				// the greeting, and the methods offered
+				if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+					return
+				}
+				conn.Write([]byte{5, 0})
+
+				// the request, with either an IPv4 address or a name
+				if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+					return
+				}
+				host := ""
+				switch buf[3] {
+				case 1:
+					io.ReadFull(conn, buf[:4])
+					host = net.IP(buf[:4]).String()
+				case 3:
+					io.ReadFull(conn, buf[:1])
+					length := int(buf[0])
+					io.ReadFull(conn, buf[:length])
+					host = string(buf[:length])
+				default:
+					return
+				}
+				io.ReadFull(conn, buf[:2])
+				mutex.Lock()
+				requested = append(requested, host)
+				mutex.Unlock()
+
+				upstream, err := net.Dial("tcp", target)
+				if err != nil {
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}(conn)
+		}
+	}()
+
+	return listener.Addr().String(), func() []string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]string{}, requested...)
+	}
+}
+
+func TestTLSHarvester_SOCKS5(t *testing.T) {
+	certificate := newTestTLSCertificate(t, "www.example.com", "api.example.com")
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{certificate}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				conn.(*tls.Conn).Handshake()
+			}(conn)
+		}
+	}()
+
+	address, requested := newTestSOCKS5Proxy(t, listener.Addr().String())
+	proxies, err := NewProxies([]string{"socks5://" + address}, ProxyRoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProxies(proxies)
+	defer SetProxies(nil)
+
+	// nothing resolves locally, the proxy is asked for the name
+	harvester := &TLSHarvester{Ports: []int{443}, Resolver: fakeTLSResolver{}}
+	names, err := harvester.Harvest(context.Background(), "www.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "www.example.com" || names[1] != "api.example.com" {
+		t.Fatalf("expected '[www.example.com api.example.com]', got '%v'", names)
+	}
+	if hosts := requested(); len(hosts) != 2 || hosts[0] != "www.example.com" {
+		t.Fatalf("expected the name to be sent to the proxy for every handshake, got '%v'", hosts)
+	}
+
+	// HTTP proxies can't tunnel the handshakes
+	proxies, _ = NewProxies([]string{"http://" + address}, ProxyRoundRobin)
+	SetProxies(proxies)
+	if _, err := DialContext(context.Background(), "tcp", "www.example.com:443"); err != errNoTunnel {
+		t.Fatalf("expected an error for an HTTP proxy, got '%v'", err)
+	}
+}
+
+func TestTLSHarvester_ClosedPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := listener.Addr().String()
+	listener.Close()
+
+	address, requested := newTestSOCKS5Proxy(t, closed)
+	proxies, err := NewProxies([]string{"socks5://" + address}, ProxyRoundRobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetProxies(proxies)
+	defer SetProxies(nil)
+
+	// a port which can't be connected to isn't tried again without SNI
+	harvester := &TLSHarvester{Ports: []int{443, 8443}, Resolver: fakeTLSResolver{}}
+	names, err := harvester.Harvest(context.Background(), "www.example.com")
+	if err != nil || len(names) != 0 {
+		t.Fatalf("expected no names, got '%v' '%v'", names, err)
+	}
+	if hosts := requested(); len(hosts) != 2 {
+		t.Fatalf("expected a single connection to each port, got '%v'", hosts)
+	}
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Watcher re-enumerates a list of root domains on a Schedule, recording every result
+// in a Store and sending the names the Store didn't know about yet to a Notifier.
+// The first run for a root domain without anything recorded only fills the Store,
+// so its existing subdomains aren't all reported as new.
+type Watcher struct {
+	Domains  []string
+	Schedule Schedule
+	Options  *EnumerationOptions
+	Store    *Store
+	Notifier Notifier      // Optional, a BatchNotifier is recommended to keep runs from waiting on it.
+	Timeout  time.Duration // Timeout for each run, defaults to 5 minutes.
+}
+
+// RunOnce enumerates every domain once, returning the new findings after notifying them.
+func (w *Watcher) RunOnce(ctx context.Context) []*Result {
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// which domains are only being filled in for the first time
+	baseline := map[string]bool{}
+	for _, domain := range w.Domains {
+		has, err := w.Store.HasDomain(domain)
+		baseline[domain] = err == nil && !has
+	}
+
+	fresh := map[*Result]bool{}
+	seen := map[string]bool{}
+	mutex := sync.Mutex{}
+
+	// only remembers results the Store doesn't know yet, it is updated right after
+	isNew := func(domain string, result *Result) bool {
+		str, ok := result.GetSuccess().(string)
+		if !result.IsSuccess() || !ok || baseline[domain] {
+			return false
+		}
+		name := NormalizeName(str)
+		mutex.Lock()
+		defer mutex.Unlock()
+		if seen[domain+" "+name] {
+			return false
+		}
+		seen[domain+" "+name] = true
+		known, err := w.Store.Known(domain, name)
+		return err == nil && !known
+	}
+
+	input := make(chan *Result)
+
+	go func() {
+		defer close(input)
+		wg := sync.WaitGroup{}
+		for _, domain := range w.Domains {
+			wg.Add(1)
+			go func(domain string) {
+				defer wg.Done()
+				for result := range EnumerateSubdomains(runCtx, domain, w.Options) {
+					if isNew(domain, result) {
+						mutex.Lock()
+						fresh[result] = true
+						mutex.Unlock()
+					}
+					select {
+					case <-runCtx.Done():
+						return
+					case input <- result:
+					}
+				}
+			}(domain)
+		}
+		wg.Wait()
+	}()
+
+	found := []*Result{}
+
+	// recording outlives the run's timeout, so nothing found is lost
+	recordCtx := ctx
+	if w.Options != nil && w.Options.Logger != nil {
+		recordCtx = WithLogger(ctx, w.Options.Logger)
+	}
+	for result := range w.Store.RecordResults(recordCtx, input) {
+		mutex.Lock()
+		isFresh := fresh[result]
+		mutex.Unlock()
+		if isFresh && result.IsSuccess() {
+			found = append(found, result)
+		}
+	}
+
+	if len(found) > 0 && w.Notifier != nil {
+		w.Notifier.Notify(ctx, found)
+	}
+
+	return found
+}
+
+// Run calls RunOnce every time the Schedule says so, until the given context is canceled.
+// The given function is called with the new findings of every run, if it isn't nil.
+func (w *Watcher) Run(ctx context.Context, found func([]*Result)) {
+	for {
+		next := w.Schedule.Next(time.Now())
+		if next.IsZero() {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		results := w.RunOnce(ctx)
+		if found != nil {
+			found(results)
+		}
+	}
+}
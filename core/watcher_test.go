@@ -0,0 +1,68 @@
+package core
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNamesSource finds whatever names it is currently set to.
+type fakeNamesSource struct {
+	names []string
+}
+
+func (s *fakeNamesSource) ProcessDomain(ctx context.Context, domain string) <-chan *Result {
+	results := make(chan *Result)
+	go func() {
+		defer close(results)
+		for _, name := range s.names {
+			results <- NewResult("fake", name+"."+domain, nil)
+		}
+	}()
+	return results
+}
+
+func collectNames(results []*Result) string {
+	names := []string{}
+	for _, result := range results {
+		names = append(names, result.Success.(string))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func TestWatcher_RunOnce(t *testing.T) {
+	store := openTestStore(t)
+	store.Resolver = fakeStoreResolver{}
+
+	source := &fakeNamesSource{names: []string{"a", "b"}}
+	notified := &blockingNotifier{}
+
+	watcher := &Watcher{
+		Domains:  []string{"example.com"},
+		Options:  &EnumerationOptions{Sources: []Source{source}},
+		Store:    store,
+		Notifier: notified,
+		Timeout:  5 * time.Second,
+	}
+
+	// the first run only fills the store
+	if found := watcher.RunOnce(context.Background()); len(found) != 0 {
+		t.Fatalf("expected nothing new on the first run, got '%v'", collectNames(found))
+	}
+
+	source.names = []string{"a", "b", "c", "C", "d"}
+	if found := collectNames(watcher.RunOnce(context.Background())); found != "c.example.com,d.example.com" {
+		t.Fatalf("expected 'c.example.com,d.example.com', got '%v'", found)
+	}
+
+	if found := watcher.RunOnce(context.Background()); len(found) != 0 {
+		t.Fatalf("expected nothing new on the third run, got '%v'", collectNames(found))
+	}
+
+	if len(notified.batches) != 1 || collectNames(notified.batches[0]) != "c.example.com,d.example.com" {
+		t.Fatalf("expected a single notification, got %d", len(notified.batches))
+	}
+}
@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"github.com/subfinder/research/core"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var errorKinds = map[string]Error_Kind{
+	core.ErrorTimeout:    Error_KIND_TIMEOUT,
+	core.ErrorCanceled:   Error_KIND_CANCELED,
+	core.ErrorNetwork:    Error_KIND_NETWORK,
+	core.ErrorHTTPStatus: Error_KIND_HTTP_STATUS,
+	core.ErrorParse:      Error_KIND_PARSE,
+}
+
+// NewError creates the Error message for the given error, or nil.
+func NewError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	return &Error{
+		Kind:       errorKinds[core.ErrorKind(err)],
+		Message:    err.Error(),
+		HttpStatus: int32(core.HTTPStatus(err)),
+	}
+}
+
+// NewFinding creates the Finding message for the given Result, following core.OutputRecord.
+func NewFinding(r *core.Result) *Finding {
+	record := core.NewOutputRecord(r)
+
+	finding := &Finding{
+		Timestamp: timestamppb.New(record.Timestamp),
+		Domain:    record.Domain,
+		Source:    record.Source,
+		Subdomain: record.Subdomain,
+		Related:   record.Related,
+		Error:     NewError(r.GetFailure()),
+	}
+
+	if cert := record.Certificate; cert != nil {
+		finding.Certificate = &Certificate{
+			Id:             cert.ID,
+			Issuer:         cert.Issuer,
+			NotBefore:      timestamppb.New(cert.NotBefore),
+			NotAfter:       timestamppb.New(cert.NotAfter),
+			Serial:         cert.Serial,
+			Expired:        cert.Expired,
+			Precertificate: cert.Precertificate,
+		}
+	}
+
+	if takeover := record.Takeover; takeover != nil {
+		finding.Takeover = &Takeover{
+			Service:    takeover.Service,
+			Cnames:     takeover.CNAMEs,
+			Target:     takeover.Target,
+			Vulnerable: takeover.Vulnerable,
+			Reason:     takeover.Reason,
+		}
+	}
+
+	for _, probe := range record.Probes {
+		finding.Probes = append(finding.Probes, &Probe{
+			Url:           probe.URL,
+			Port:          int32(probe.Port),
+			StatusCode:    int32(probe.StatusCode),
+			Title:         probe.Title,
+			ContentLength: probe.ContentLength,
+			Server:        probe.Server,
+			TlsSubject:    probe.TLSSubject,
+			TlsDnsNames:   probe.TLSDNSNames,
+			FinalUrl:      probe.FinalURL,
+		})
+	}
+
+	return finding
+}
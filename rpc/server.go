@@ -0,0 +1,296 @@
+/*
+Package rpc exposes subdomain enumeration over gRPC, as defined in
+subzero.proto, for services which talk gRPC instead of the REST API of
+package server. Enumerations run for as long as the call which started
+them, so a client canceling the call cancels every source.
+*/
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative subzero.proto
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/subfinder/research/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// checkDomain is the domain sources are checked with, unless another is asked for.
+const checkDomain = "google.com"
+
+// Server implements the Subzero gRPC service.
+type Server struct {
+	UnimplementedSubzeroServer
+	Sources        []core.Source         // Sources which enumerations can use.
+	APIKeys        []string              // Keys clients authenticate with, no authentication is done when empty.
+	DefaultTimeout time.Duration         // Timeout of enumerations which don't set one, defaults to 5 minutes.
+	MaxTimeout     time.Duration         // Longest timeout an enumeration can ask for, defaults to 1 hour.
+	CheckTimeout   time.Duration         // Timeout of source checks which don't set one, defaults to 30 seconds.
+	Prober         *core.Prober          // Used by enumerations asking for probes, defaults to an empty Prober.
+	Checker        *core.TakeoverChecker // Used by enumerations asking for takeover checks, defaults to an empty TakeoverChecker.
+	Metrics        *core.Metrics         // Metrics to count the sources of enumerations in, if any.
+	Logger         *slog.Logger          // Logger the sources of enumerations log to, if any.
+	once           sync.Once
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		if s.DefaultTimeout <= 0 {
+			s.DefaultTimeout = 5 * time.Minute
+		}
+		if s.MaxTimeout <= 0 {
+			s.MaxTimeout = time.Hour
+		}
+		if s.CheckTimeout <= 0 {
+			s.CheckTimeout = 30 * time.Second
+		}
+		if s.Prober == nil {
+			s.Prober = &core.Prober{}
+		}
+		if s.Checker == nil {
+			s.Checker = &core.TakeoverChecker{}
+		}
+	})
+}
+
+// NewGRPCServer creates a grpc.Server with the given Server registered, behind its
+// API keys. When any are set, every call needs one of them in either an
+// "authorization: Bearer <key>" or "x-api-key: <key>" metadata entry.
+func NewGRPCServer(s *Server, options ...grpc.ServerOption) *grpc.Server {
+	options = append(options,
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.authorize(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authorize(stream.Context()); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	)
+	server := grpc.NewServer(options...)
+	RegisterSubzeroServer(server, s)
+	return server
+}
+
+// authorize checks the call's API key, if there are any.
+func (s *Server) authorize(ctx context.Context) error {
+	if len(s.APIKeys) == 0 {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	key := ""
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		key = keys[0]
+	}
+	if auth := md.Get("authorization"); len(auth) > 0 && strings.HasPrefix(auth[0], "Bearer ") {
+		key = strings.TrimPrefix(auth[0], "Bearer ")
+	}
+
+	if key != "" {
+		for _, allowed := range s.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(allowed)) == 1 {
+				return nil
+			}
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid API key")
+}
+
+// timeout returns the timeout asked for, or the given default.
+func (s *Server) timeout(requested *durationpb.Duration, fallback time.Duration) (time.Duration, error) {
+	if requested == nil {
+		return fallback, nil
+	}
+	if err := requested.CheckValid(); err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid timeout: %v", err)
+	}
+	timeout := requested.AsDuration()
+	if timeout <= 0 {
+		return fallback, nil
+	}
+	if timeout > s.MaxTimeout {
+		timeout = s.MaxTimeout
+	}
+	return timeout, nil
+}
+
+// selectSources returns the sources with the given names, or an InvalidArgument error.
+func (s *Server) selectSources(names []string) ([]core.Source, error) {
+	sources, err := core.SelectSources(s.Sources, names)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return sources, nil
+}
+
+// normalizeDomain returns the given domain normalized, or an InvalidArgument error.
+func normalizeDomain(domain string) (string, error) {
+	normalized := core.NormalizeName(domain)
+	if normalized == "" || strings.ContainsAny(normalized, " /:") {
+		return "", status.Errorf(codes.InvalidArgument, "invalid domain %q", domain)
+	}
+	return normalized, nil
+}
+
+// Enumerate streams every result for the requested domain as it's found.
+func (s *Server) Enumerate(request *EnumerateRequest, stream Subzero_EnumerateServer) error {
+	s.init()
+
+	domain, err := normalizeDomain(request.GetDomain())
+	if err != nil {
+		return err
+	}
+	sources, err := s.selectSources(request.GetSources())
+	if err != nil {
+		return err
+	}
+	timeout, err := s.timeout(request.GetTimeout(), s.DefaultTimeout)
+	if err != nil {
+		return err
+	}
+
+	// the call's context is canceled once the client goes away
+	cancelCtx := stream.Context()
+	ctx, cancel := context.WithTimeout(cancelCtx, timeout)
+	defer cancel()
+
+	options := &core.EnumerationOptions{
+		Sources:        sources,
+		Context:        ctx,
+		Recursive:      request.GetRecursive(),
+		Debug:          request.GetDebug(),
+		Uniq:           request.GetUniq(),
+		ExcludeExpired: request.GetExcludeExpired(),
+		Metrics:        s.Metrics,
+		Logger:         s.Logger,
+	}
+
+	output := core.EnumerateSubdomains(ctx, domain, options)
+	// checks finish what was found even after a timeout, but not a cancellation
+	if request.GetTakeover() {
+		output = s.Checker.CheckResults(cancelCtx, output)
+	}
+	if request.GetProbe() {
+		output = s.Prober.ProbeResults(cancelCtx, output)
+	}
+
+	var sendErr error
+	for result := range output {
+		if sendErr != nil {
+			continue
+		}
+		if sendErr = stream.Send(NewFinding(result)); sendErr != nil {
+			cancel()
+		}
+	}
+
+	if sendErr != nil {
+		return sendErr
+	}
+	if err := cancelCtx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	state := "done"
+	if ctx.Err() == context.DeadlineExceeded {
+		state = "timeout"
+	}
+	stream.SetTrailer(metadata.Pairs("subzero-state", state))
+	return nil
+}
+
+// ListSources lists the names of the available sources.
+func (s *Server) ListSources(ctx context.Context, request *ListSourcesRequest) (*ListSourcesResponse, error) {
+	names := []string{}
+	for _, source := range s.Sources {
+		names = append(names, core.SourceName(source))
+	}
+	sort.Strings(names)
+	return &ListSourcesResponse{Sources: names}, nil
+}
+
+// CheckSources queries every requested source at once, reporting how each of them did.
+func (s *Server) CheckSources(ctx context.Context, request *CheckSourcesRequest) (*CheckSourcesResponse, error) {
+	s.init()
+
+	domain := checkDomain
+	if request.GetDomain() != "" {
+		var err error
+		if domain, err = normalizeDomain(request.GetDomain()); err != nil {
+			return nil, err
+		}
+	}
+	sources, err := s.selectSources(request.GetSources())
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := s.timeout(request.GetTimeout(), s.CheckTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	checks := make([]*SourceCheck, len(sources))
+	wg := sync.WaitGroup{}
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source core.Source) {
+			defer wg.Done()
+			checks[i] = checkSource(ctx, source, domain)
+		}(i, source)
+	}
+	wg.Wait()
+
+	// a client going away isn't the fault of the sources
+	if err := ctx.Err(); err != nil && err != context.DeadlineExceeded {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	return &CheckSourcesResponse{Checks: checks}, nil
+}
+
+// checkSource queries a single source until it's done or the context is.
+func checkSource(ctx context.Context, source core.Source, domain string) *SourceCheck {
+	check := &SourceCheck{Source: core.SourceName(source)}
+	start := time.Now()
+
+	for result := range source.ProcessDomain(ctx, domain) {
+		if err := result.GetFailure(); err != nil {
+			if check.Error == nil {
+				check.Error = NewError(err)
+			}
+			check.Errors++
+		} else {
+			check.Results++
+		}
+	}
+
+	// sources still running once the context is done didn't finish in time
+	if err := ctx.Err(); err != nil {
+		if check.Error == nil {
+			check.Error = NewError(err)
+		}
+		check.Errors++
+	}
+
+	check.Duration = durationpb.New(time.Since(start))
+	check.Ok = check.Results > 0 && check.Errors == 0
+	return check
+}
@@ -0,0 +1,215 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/subfinder/research/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// FakeSource finds a few names and runs into an error right away.
+type FakeSource struct{}
+
+func (s *FakeSource) ProcessDomain(ctx context.Context, domain string) <-chan *core.Result {
+	results := make(chan *core.Result)
+	go func() {
+		defer close(results)
+		for _, result := range []*core.Result{
+			core.NewResult("fake", "a."+domain, nil),
+			core.NewResult("fake", "b."+domain, nil),
+			core.NewResult("fake", nil, errors.New("503 Service Unavailable")),
+		} {
+			select {
+			case results <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// SlowSource finds nothing until its context is done, reporting when it starts and stops.
+type SlowSource struct {
+	Started chan struct{}
+	Done    chan error
+}
+
+func newSlowSource() *SlowSource {
+	return &SlowSource{Started: make(chan struct{}, 1), Done: make(chan error, 1)}
+}
+
+func (s *SlowSource) ProcessDomain(ctx context.Context, domain string) <-chan *core.Result {
+	results := make(chan *core.Result)
+	go func() {
+		defer close(results)
+		s.Started <- struct{}{}
+		<-ctx.Done()
+		s.Done <- ctx.Err()
+	}()
+	return results
+}
+
+func newTestClient(t *testing.T, s *Server) SubzeroClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(s)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewSubzeroClient(conn)
+}
+
+func withKey(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+}
+
+func TestServer_Authentication(t *testing.T) {
+	client := newTestClient(t, &Server{Sources: []core.Source{&FakeSource{}}, APIKeys: []string{"secret"}})
+
+	_, err := client.ListSources(context.Background(), &ListSourcesRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without a key, got '%v'", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "wrong")
+	if _, err := client.ListSources(ctx, &ListSourcesRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated with a wrong key, got '%v'", err)
+	}
+
+	stream, err := client.Enumerate(context.Background(), &EnumerateRequest{Domain: "example.com"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated enumerating without a key, got '%v'", err)
+	}
+
+	response, err := client.ListSources(withKey(context.Background()), &ListSourcesRequest{})
+	if err != nil || strings.Join(response.GetSources(), ",") != "FakeSource" {
+		t.Fatalf("expected 'FakeSource', got '%v' '%v'", response, err)
+	}
+}
+
+func TestServer_Enumerate(t *testing.T) {
+	slow := newSlowSource()
+	client := newTestClient(t, &Server{Sources: []core.Source{&FakeSource{}, slow}})
+
+	stream, err := client.Enumerate(context.Background(), &EnumerateRequest{Domain: "example.com", Sources: []string{"nope"}})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an unknown source, got '%v'", err)
+	}
+
+	stream, err = client.Enumerate(context.Background(), &EnumerateRequest{
+		Domain:  "Example.com.",
+		Sources: []string{"fakesource", "slowsource"},
+		Timeout: durationpb.New(100 * time.Millisecond),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{}
+	var failure *Error
+	for {
+		finding, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if finding.GetDomain() != "example.com" || finding.GetSource() != "fake" {
+			t.Fatalf("expected a fake finding for example.com, got '%v'", finding)
+		}
+		if finding.GetError() != nil {
+			failure = finding.GetError()
+		} else {
+			names = append(names, finding.GetSubdomain())
+		}
+	}
+
+	if strings.Join(names, ",") != "a.example.com,b.example.com" {
+		t.Fatalf("expected 'a.example.com,b.example.com', got '%v'", names)
+	}
+	if failure.GetKind() != Error_KIND_HTTP_STATUS || failure.GetHttpStatus() != 503 || failure.GetMessage() != "503 Service Unavailable" {
+		t.Fatalf("expected a 503 error, got '%v'", failure)
+	}
+	if state := stream.Trailer().Get("subzero-state"); len(state) != 1 || state[0] != "timeout" {
+		t.Fatalf("expected the 'timeout' state, got '%v'", state)
+	}
+	if err := <-slow.Done; err != context.DeadlineExceeded {
+		t.Fatalf("expected the slow source to time out, got '%v'", err)
+	}
+}
+
+func TestServer_EnumerateCanceled(t *testing.T) {
+	slow := newSlowSource()
+	client := newTestClient(t, &Server{Sources: []core.Source{slow}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Enumerate(ctx, &EnumerateRequest{Domain: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-slow.Started
+	cancel()
+
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("expected the call to be canceled, got '%v'", err)
+	}
+
+	select {
+	case err := <-slow.Done:
+		if err != context.Canceled {
+			t.Fatalf("expected the source to be canceled, got '%v'", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the source wasn't canceled")
+	}
+}
+
+func TestServer_CheckSources(t *testing.T) {
+	slow := newSlowSource()
+	client := newTestClient(t, &Server{Sources: []core.Source{&FakeSource{}, slow}})
+
+	response, err := client.CheckSources(context.Background(), &CheckSourcesRequest{Timeout: durationpb.New(100 * time.Millisecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checks := response.GetChecks()
+	if len(checks) != 2 {
+		t.Fatalf("expected 2 checks, got '%v'", checks)
+	}
+	if fake := checks[0]; fake.GetSource() != "FakeSource" || fake.GetOk() || fake.GetResults() != 2 || fake.GetErrors() != 1 || fake.GetError().GetKind() != Error_KIND_HTTP_STATUS {
+		t.Fatalf("expected FakeSource to find 2 names and a 503, got '%v'", fake)
+	}
+	if slow := checks[1]; slow.GetSource() != "SlowSource" || slow.GetOk() || slow.GetError().GetKind() != Error_KIND_TIMEOUT {
+		t.Fatalf("expected SlowSource to time out, got '%v'", slow)
+	}
+}
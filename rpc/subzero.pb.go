@@ -0,0 +1,1134 @@
+// The gRPC API of subzero, for services which want subdomain data without
+// shelling out to the CLI.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: subzero.proto
+
+package rpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Kind is the broad category of an error.
+type Error_Kind int32
+
+const (
+	Error_KIND_UNSPECIFIED Error_Kind = 0
+	// The source timed out.
+	Error_KIND_TIMEOUT Error_Kind = 1
+	// The source was canceled.
+	Error_KIND_CANCELED Error_Kind = 2
+	// The source couldn't be reached.
+	Error_KIND_NETWORK Error_Kind = 3
+	// The source responded with an unexpected HTTP status.
+	Error_KIND_HTTP_STATUS Error_Kind = 4
+	// The source's response couldn't be parsed.
+	Error_KIND_PARSE Error_Kind = 5
+)
+
+// Enum value maps for Error_Kind.
+var (
+	Error_Kind_name = map[int32]string{
+		0: "KIND_UNSPECIFIED",
+		1: "KIND_TIMEOUT",
+		2: "KIND_CANCELED",
+		3: "KIND_NETWORK",
+		4: "KIND_HTTP_STATUS",
+		5: "KIND_PARSE",
+	}
+	Error_Kind_value = map[string]int32{
+		"KIND_UNSPECIFIED": 0,
+		"KIND_TIMEOUT":     1,
+		"KIND_CANCELED":    2,
+		"KIND_NETWORK":     3,
+		"KIND_HTTP_STATUS": 4,
+		"KIND_PARSE":       5,
+	}
+)
+
+func (x Error_Kind) Enum() *Error_Kind {
+	p := new(Error_Kind)
+	*p = x
+	return p
+}
+
+func (x Error_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Error_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_subzero_proto_enumTypes[0].Descriptor()
+}
+
+func (Error_Kind) Type() protoreflect.EnumType {
+	return &file_subzero_proto_enumTypes[0]
+}
+
+func (x Error_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Error_Kind.Descriptor instead.
+func (Error_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{2, 0}
+}
+
+// EnumerateRequest holds the options of an enumeration, mapping to core.EnumerationOptions.
+type EnumerateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The domain to enumerate.
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Names of the sources to use, matched case insensitively, defaults to all of them.
+	Sources []string `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"`
+	// Use results to find more results.
+	Recursive bool `protobuf:"varint,3,opt,name=recursive,proto3" json:"recursive,omitempty"`
+	// Enable the sources' diagnostic output.
+	Debug bool `protobuf:"varint,4,opt,name=debug,proto3" json:"debug,omitempty"`
+	// Filter out duplicate results.
+	Uniq bool `protobuf:"varint,5,opt,name=uniq,proto3" json:"uniq,omitempty"`
+	// Drop results only found on expired certificates.
+	ExcludeExpired bool `protobuf:"varint,6,opt,name=exclude_expired,json=excludeExpired,proto3" json:"exclude_expired,omitempty"`
+	// Probe results over HTTP and HTTPS, to tell which are live.
+	Probe bool `protobuf:"varint,7,opt,name=probe,proto3" json:"probe,omitempty"`
+	// Check results for subdomain takeovers.
+	Takeover bool `protobuf:"varint,8,opt,name=takeover,proto3" json:"takeover,omitempty"`
+	// How long the sources are queried for, defaults to the server's.
+	Timeout       *durationpb.Duration `protobuf:"bytes,9,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnumerateRequest) Reset() {
+	*x = EnumerateRequest{}
+	mi := &file_subzero_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnumerateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnumerateRequest) ProtoMessage() {}
+
+func (x *EnumerateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnumerateRequest.ProtoReflect.Descriptor instead.
+func (*EnumerateRequest) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *EnumerateRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *EnumerateRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *EnumerateRequest) GetRecursive() bool {
+	if x != nil {
+		return x.Recursive
+	}
+	return false
+}
+
+func (x *EnumerateRequest) GetDebug() bool {
+	if x != nil {
+		return x.Debug
+	}
+	return false
+}
+
+func (x *EnumerateRequest) GetUniq() bool {
+	if x != nil {
+		return x.Uniq
+	}
+	return false
+}
+
+func (x *EnumerateRequest) GetExcludeExpired() bool {
+	if x != nil {
+		return x.ExcludeExpired
+	}
+	return false
+}
+
+func (x *EnumerateRequest) GetProbe() bool {
+	if x != nil {
+		return x.Probe
+	}
+	return false
+}
+
+func (x *EnumerateRequest) GetTakeover() bool {
+	if x != nil {
+		return x.Takeover
+	}
+	return false
+}
+
+func (x *EnumerateRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+// Finding is a single result of an enumeration, mapping to core.OutputRecord.
+type Finding struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// When the finding was made.
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// The root domain being enumerated.
+	Domain string `protobuf:"bytes,2,opt,name=domain,proto3" json:"domain,omitempty"`
+	// The source which made the finding.
+	Source string `protobuf:"bytes,3,opt,name=source,proto3" json:"source,omitempty"`
+	// The name found, empty for errors.
+	Subdomain string `protobuf:"bytes,4,opt,name=subdomain,proto3" json:"subdomain,omitempty"`
+	// Whether the name belongs to another apex domain.
+	Related bool `protobuf:"varint,5,opt,name=related,proto3" json:"related,omitempty"`
+	// The error the source ran into, instead of a name.
+	Error *Error `protobuf:"bytes,6,opt,name=error,proto3" json:"error,omitempty"`
+	// The certificate the name was found on.
+	Certificate *Certificate `protobuf:"bytes,7,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	// The outcome of a takeover check.
+	Takeover *Takeover `protobuf:"bytes,8,opt,name=takeover,proto3" json:"takeover,omitempty"`
+	// The outcome of HTTP(S) probing, which is empty for names which aren't live.
+	Probes        []*Probe `protobuf:"bytes,9,rep,name=probes,proto3" json:"probes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Finding) Reset() {
+	*x = Finding{}
+	mi := &file_subzero_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Finding) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Finding) ProtoMessage() {}
+
+func (x *Finding) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Finding.ProtoReflect.Descriptor instead.
+func (*Finding) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Finding) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Finding) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *Finding) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Finding) GetSubdomain() string {
+	if x != nil {
+		return x.Subdomain
+	}
+	return ""
+}
+
+func (x *Finding) GetRelated() bool {
+	if x != nil {
+		return x.Related
+	}
+	return false
+}
+
+func (x *Finding) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *Finding) GetCertificate() *Certificate {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+func (x *Finding) GetTakeover() *Takeover {
+	if x != nil {
+		return x.Takeover
+	}
+	return nil
+}
+
+func (x *Finding) GetProbes() []*Probe {
+	if x != nil {
+		return x.Probes
+	}
+	return nil
+}
+
+// Error is an error a source ran into.
+type Error struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Kind  Error_Kind             `protobuf:"varint,1,opt,name=kind,proto3,enum=subzero.v1.Error_Kind" json:"kind,omitempty"`
+	// The error string.
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// The HTTP status code, for KIND_HTTP_STATUS errors.
+	HttpStatus    int32 `protobuf:"varint,3,opt,name=http_status,json=httpStatus,proto3" json:"http_status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Error) Reset() {
+	*x = Error{}
+	mi := &file_subzero_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Error) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Error) ProtoMessage() {}
+
+func (x *Error) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Error.ProtoReflect.Descriptor instead.
+func (*Error) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Error) GetKind() Error_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return Error_KIND_UNSPECIFIED
+}
+
+func (x *Error) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Error) GetHttpStatus() int32 {
+	if x != nil {
+		return x.HttpStatus
+	}
+	return 0
+}
+
+// Certificate is the metadata of a certificate a name was found on.
+type Certificate struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifier of the certificate at the source.
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Issuer         string                 `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	NotBefore      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	NotAfter       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	Serial         string                 `protobuf:"bytes,5,opt,name=serial,proto3" json:"serial,omitempty"`
+	Expired        bool                   `protobuf:"varint,6,opt,name=expired,proto3" json:"expired,omitempty"`
+	Precertificate bool                   `protobuf:"varint,7,opt,name=precertificate,proto3" json:"precertificate,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Certificate) Reset() {
+	*x = Certificate{}
+	mi := &file_subzero_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Certificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Certificate) ProtoMessage() {}
+
+func (x *Certificate) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Certificate.ProtoReflect.Descriptor instead.
+func (*Certificate) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Certificate) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Certificate) GetIssuer() string {
+	if x != nil {
+		return x.Issuer
+	}
+	return ""
+}
+
+func (x *Certificate) GetNotBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotBefore
+	}
+	return nil
+}
+
+func (x *Certificate) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+func (x *Certificate) GetSerial() string {
+	if x != nil {
+		return x.Serial
+	}
+	return ""
+}
+
+func (x *Certificate) GetExpired() bool {
+	if x != nil {
+		return x.Expired
+	}
+	return false
+}
+
+func (x *Certificate) GetPrecertificate() bool {
+	if x != nil {
+		return x.Precertificate
+	}
+	return false
+}
+
+// Takeover is the outcome of a subdomain takeover check.
+type Takeover struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The service whose fingerprint matched.
+	Service string `protobuf:"bytes,1,opt,name=service,proto3" json:"service,omitempty"`
+	// The CNAME chain that was followed.
+	Cnames []string `protobuf:"bytes,2,rep,name=cnames,proto3" json:"cnames,omitempty"`
+	// The last CNAME target in the chain.
+	Target string `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	// Whether the takeover was confirmed.
+	Vulnerable bool `protobuf:"varint,4,opt,name=vulnerable,proto3" json:"vulnerable,omitempty"`
+	// Why the name was (or wasn't) flagged.
+	Reason        string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Takeover) Reset() {
+	*x = Takeover{}
+	mi := &file_subzero_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Takeover) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Takeover) ProtoMessage() {}
+
+func (x *Takeover) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Takeover.ProtoReflect.Descriptor instead.
+func (*Takeover) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Takeover) GetService() string {
+	if x != nil {
+		return x.Service
+	}
+	return ""
+}
+
+func (x *Takeover) GetCnames() []string {
+	if x != nil {
+		return x.Cnames
+	}
+	return nil
+}
+
+func (x *Takeover) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Takeover) GetVulnerable() bool {
+	if x != nil {
+		return x.Vulnerable
+	}
+	return false
+}
+
+func (x *Takeover) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// Probe is what was found while connecting to a name over HTTP or HTTPS.
+type Probe struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The URL that was probed.
+	Url string `protobuf:"bytes,1,opt,name=url,proto3" json:"url,omitempty"`
+	// The port that was probed.
+	Port int32 `protobuf:"varint,2,opt,name=port,proto3" json:"port,omitempty"`
+	// Status code of the final response.
+	StatusCode int32 `protobuf:"varint,3,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	// HTML title of the final response.
+	Title string `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	// Length of the final response body.
+	ContentLength int64 `protobuf:"varint,5,opt,name=content_length,json=contentLength,proto3" json:"content_length,omitempty"`
+	// Server header of the final response.
+	Server string `protobuf:"bytes,6,opt,name=server,proto3" json:"server,omitempty"`
+	// Subject of the TLS certificate presented.
+	TlsSubject string `protobuf:"bytes,7,opt,name=tls_subject,json=tlsSubject,proto3" json:"tls_subject,omitempty"`
+	// SANs of the TLS certificate presented.
+	TlsDnsNames []string `protobuf:"bytes,8,rep,name=tls_dns_names,json=tlsDnsNames,proto3" json:"tls_dns_names,omitempty"`
+	// The URL after following redirects.
+	FinalUrl      string `protobuf:"bytes,9,opt,name=final_url,json=finalUrl,proto3" json:"final_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Probe) Reset() {
+	*x = Probe{}
+	mi := &file_subzero_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Probe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Probe) ProtoMessage() {}
+
+func (x *Probe) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Probe.ProtoReflect.Descriptor instead.
+func (*Probe) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Probe) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *Probe) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *Probe) GetStatusCode() int32 {
+	if x != nil {
+		return x.StatusCode
+	}
+	return 0
+}
+
+func (x *Probe) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Probe) GetContentLength() int64 {
+	if x != nil {
+		return x.ContentLength
+	}
+	return 0
+}
+
+func (x *Probe) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *Probe) GetTlsSubject() string {
+	if x != nil {
+		return x.TlsSubject
+	}
+	return ""
+}
+
+func (x *Probe) GetTlsDnsNames() []string {
+	if x != nil {
+		return x.TlsDnsNames
+	}
+	return nil
+}
+
+func (x *Probe) GetFinalUrl() string {
+	if x != nil {
+		return x.FinalUrl
+	}
+	return ""
+}
+
+type ListSourcesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSourcesRequest) Reset() {
+	*x = ListSourcesRequest{}
+	mi := &file_subzero_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSourcesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSourcesRequest) ProtoMessage() {}
+
+func (x *ListSourcesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSourcesRequest.ProtoReflect.Descriptor instead.
+func (*ListSourcesRequest) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{6}
+}
+
+type ListSourcesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The names of the available sources, sorted.
+	Sources       []string `protobuf:"bytes,1,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSourcesResponse) Reset() {
+	*x = ListSourcesResponse{}
+	mi := &file_subzero_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSourcesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSourcesResponse) ProtoMessage() {}
+
+func (x *ListSourcesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSourcesResponse.ProtoReflect.Descriptor instead.
+func (*ListSourcesResponse) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListSourcesResponse) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type CheckSourcesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The domain to query the sources for, defaults to google.com.
+	Domain string `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	// Names of the sources to check, defaults to all of them.
+	Sources []string `protobuf:"bytes,2,rep,name=sources,proto3" json:"sources,omitempty"`
+	// How long each source is queried for, defaults to the server's.
+	Timeout       *durationpb.Duration `protobuf:"bytes,3,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSourcesRequest) Reset() {
+	*x = CheckSourcesRequest{}
+	mi := &file_subzero_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSourcesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSourcesRequest) ProtoMessage() {}
+
+func (x *CheckSourcesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSourcesRequest.ProtoReflect.Descriptor instead.
+func (*CheckSourcesRequest) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CheckSourcesRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *CheckSourcesRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *CheckSourcesRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+type CheckSourcesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The outcome for each source, in the order of the request.
+	Checks        []*SourceCheck `protobuf:"bytes,1,rep,name=checks,proto3" json:"checks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckSourcesResponse) Reset() {
+	*x = CheckSourcesResponse{}
+	mi := &file_subzero_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckSourcesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckSourcesResponse) ProtoMessage() {}
+
+func (x *CheckSourcesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckSourcesResponse.ProtoReflect.Descriptor instead.
+func (*CheckSourcesResponse) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CheckSourcesResponse) GetChecks() []*SourceCheck {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+// SourceCheck is the outcome of querying a single source.
+type SourceCheck struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Source string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// Whether the source found anything without running into errors.
+	Ok bool `protobuf:"varint,2,opt,name=ok,proto3" json:"ok,omitempty"`
+	// Number of names found.
+	Results int64 `protobuf:"varint,3,opt,name=results,proto3" json:"results,omitempty"`
+	// Number of errors run into.
+	Errors int64 `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
+	// The first error run into.
+	Error *Error `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	// How long the source took to finish.
+	Duration      *durationpb.Duration `protobuf:"bytes,6,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourceCheck) Reset() {
+	*x = SourceCheck{}
+	mi := &file_subzero_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourceCheck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceCheck) ProtoMessage() {}
+
+func (x *SourceCheck) ProtoReflect() protoreflect.Message {
+	mi := &file_subzero_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceCheck.ProtoReflect.Descriptor instead.
+func (*SourceCheck) Descriptor() ([]byte, []int) {
+	return file_subzero_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SourceCheck) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SourceCheck) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SourceCheck) GetResults() int64 {
+	if x != nil {
+		return x.Results
+	}
+	return 0
+}
+
+func (x *SourceCheck) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *SourceCheck) GetError() *Error {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *SourceCheck) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+var File_subzero_proto protoreflect.FileDescriptor
+
+const file_subzero_proto_rawDesc = "" +
+	"\n" +
+	"\rsubzero.proto\x12\n" +
+	"subzero.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9c\x02\n" +
+	"\x10EnumerateRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x18\n" +
+	"\asources\x18\x02 \x03(\tR\asources\x12\x1c\n" +
+	"\trecursive\x18\x03 \x01(\bR\trecursive\x12\x14\n" +
+	"\x05debug\x18\x04 \x01(\bR\x05debug\x12\x12\n" +
+	"\x04uniq\x18\x05 \x01(\bR\x04uniq\x12'\n" +
+	"\x0fexclude_expired\x18\x06 \x01(\bR\x0eexcludeExpired\x12\x14\n" +
+	"\x05probe\x18\a \x01(\bR\x05probe\x12\x1a\n" +
+	"\btakeover\x18\b \x01(\bR\btakeover\x123\n" +
+	"\atimeout\x18\t \x01(\v2\x19.google.protobuf.DurationR\atimeout\"\xec\x02\n" +
+	"\aFinding\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
+	"\x06domain\x18\x02 \x01(\tR\x06domain\x12\x16\n" +
+	"\x06source\x18\x03 \x01(\tR\x06source\x12\x1c\n" +
+	"\tsubdomain\x18\x04 \x01(\tR\tsubdomain\x12\x18\n" +
+	"\arelated\x18\x05 \x01(\bR\arelated\x12'\n" +
+	"\x05error\x18\x06 \x01(\v2\x11.subzero.v1.ErrorR\x05error\x129\n" +
+	"\vcertificate\x18\a \x01(\v2\x17.subzero.v1.CertificateR\vcertificate\x120\n" +
+	"\btakeover\x18\b \x01(\v2\x14.subzero.v1.TakeoverR\btakeover\x12)\n" +
+	"\x06probes\x18\t \x03(\v2\x11.subzero.v1.ProbeR\x06probes\"\xe9\x01\n" +
+	"\x05Error\x12*\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x16.subzero.v1.Error.KindR\x04kind\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
+	"\vhttp_status\x18\x03 \x01(\x05R\n" +
+	"httpStatus\"y\n" +
+	"\x04Kind\x12\x14\n" +
+	"\x10KIND_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fKIND_TIMEOUT\x10\x01\x12\x11\n" +
+	"\rKIND_CANCELED\x10\x02\x12\x10\n" +
+	"\fKIND_NETWORK\x10\x03\x12\x14\n" +
+	"\x10KIND_HTTP_STATUS\x10\x04\x12\x0e\n" +
+	"\n" +
+	"KIND_PARSE\x10\x05\"\x83\x02\n" +
+	"\vCertificate\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06issuer\x18\x02 \x01(\tR\x06issuer\x129\n" +
+	"\n" +
+	"not_before\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tnotBefore\x127\n" +
+	"\tnot_after\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\bnotAfter\x12\x16\n" +
+	"\x06serial\x18\x05 \x01(\tR\x06serial\x12\x18\n" +
+	"\aexpired\x18\x06 \x01(\bR\aexpired\x12&\n" +
+	"\x0eprecertificate\x18\a \x01(\bR\x0eprecertificate\"\x8c\x01\n" +
+	"\bTakeover\x12\x18\n" +
+	"\aservice\x18\x01 \x01(\tR\aservice\x12\x16\n" +
+	"\x06cnames\x18\x02 \x03(\tR\x06cnames\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\tR\x06target\x12\x1e\n" +
+	"\n" +
+	"vulnerable\x18\x04 \x01(\bR\n" +
+	"vulnerable\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\"\x85\x02\n" +
+	"\x05Probe\x12\x10\n" +
+	"\x03url\x18\x01 \x01(\tR\x03url\x12\x12\n" +
+	"\x04port\x18\x02 \x01(\x05R\x04port\x12\x1f\n" +
+	"\vstatus_code\x18\x03 \x01(\x05R\n" +
+	"statusCode\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12%\n" +
+	"\x0econtent_length\x18\x05 \x01(\x03R\rcontentLength\x12\x16\n" +
+	"\x06server\x18\x06 \x01(\tR\x06server\x12\x1f\n" +
+	"\vtls_subject\x18\a \x01(\tR\n" +
+	"tlsSubject\x12\"\n" +
+	"\rtls_dns_names\x18\b \x03(\tR\vtlsDnsNames\x12\x1b\n" +
+	"\tfinal_url\x18\t \x01(\tR\bfinalUrl\"\x14\n" +
+	"\x12ListSourcesRequest\"/\n" +
+	"\x13ListSourcesResponse\x12\x18\n" +
+	"\asources\x18\x01 \x03(\tR\asources\"|\n" +
+	"\x13CheckSourcesRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x18\n" +
+	"\asources\x18\x02 \x03(\tR\asources\x123\n" +
+	"\atimeout\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\atimeout\"G\n" +
+	"\x14CheckSourcesResponse\x12/\n" +
+	"\x06checks\x18\x01 \x03(\v2\x17.subzero.v1.SourceCheckR\x06checks\"\xc7\x01\n" +
+	"\vSourceCheck\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x0e\n" +
+	"\x02ok\x18\x02 \x01(\bR\x02ok\x12\x18\n" +
+	"\aresults\x18\x03 \x01(\x03R\aresults\x12\x16\n" +
+	"\x06errors\x18\x04 \x01(\x03R\x06errors\x12'\n" +
+	"\x05error\x18\x05 \x01(\v2\x11.subzero.v1.ErrorR\x05error\x125\n" +
+	"\bduration\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\bduration2\xee\x01\n" +
+	"\aSubzero\x12@\n" +
+	"\tEnumerate\x12\x1c.subzero.v1.EnumerateRequest\x1a\x13.subzero.v1.Finding0\x01\x12N\n" +
+	"\vListSources\x12\x1e.subzero.v1.ListSourcesRequest\x1a\x1f.subzero.v1.ListSourcesResponse\x12Q\n" +
+	"\fCheckSources\x12\x1f.subzero.v1.CheckSourcesRequest\x1a .subzero.v1.CheckSourcesResponseB#Z!github.com/subfinder/research/rpcb\x06proto3"
+
+var (
+	file_subzero_proto_rawDescOnce sync.Once
+	file_subzero_proto_rawDescData []byte
+)
+
+func file_subzero_proto_rawDescGZIP() []byte {
+	file_subzero_proto_rawDescOnce.Do(func() {
+		file_subzero_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_subzero_proto_rawDesc), len(file_subzero_proto_rawDesc)))
+	})
+	return file_subzero_proto_rawDescData
+}
+
+var file_subzero_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_subzero_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_subzero_proto_goTypes = []any{
+	(Error_Kind)(0),               // 0: subzero.v1.Error.Kind
+	(*EnumerateRequest)(nil),      // 1: subzero.v1.EnumerateRequest
+	(*Finding)(nil),               // 2: subzero.v1.Finding
+	(*Error)(nil),                 // 3: subzero.v1.Error
+	(*Certificate)(nil),           // 4: subzero.v1.Certificate
+	(*Takeover)(nil),              // 5: subzero.v1.Takeover
+	(*Probe)(nil),                 // 6: subzero.v1.Probe
+	(*ListSourcesRequest)(nil),    // 7: subzero.v1.ListSourcesRequest
+	(*ListSourcesResponse)(nil),   // 8: subzero.v1.ListSourcesResponse
+	(*CheckSourcesRequest)(nil),   // 9: subzero.v1.CheckSourcesRequest
+	(*CheckSourcesResponse)(nil),  // 10: subzero.v1.CheckSourcesResponse
+	(*SourceCheck)(nil),           // 11: subzero.v1.SourceCheck
+	(*durationpb.Duration)(nil),   // 12: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
+}
+var file_subzero_proto_depIdxs = []int32{
+	12, // 0: subzero.v1.EnumerateRequest.timeout:type_name -> google.protobuf.Duration
+	13, // 1: subzero.v1.Finding.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 2: subzero.v1.Finding.error:type_name -> subzero.v1.Error
+	4,  // 3: subzero.v1.Finding.certificate:type_name -> subzero.v1.Certificate
+	5,  // 4: subzero.v1.Finding.takeover:type_name -> subzero.v1.Takeover
+	6,  // 5: subzero.v1.Finding.probes:type_name -> subzero.v1.Probe
+	0,  // 6: subzero.v1.Error.kind:type_name -> subzero.v1.Error.Kind
+	13, // 7: subzero.v1.Certificate.not_before:type_name -> google.protobuf.Timestamp
+	13, // 8: subzero.v1.Certificate.not_after:type_name -> google.protobuf.Timestamp
+	12, // 9: subzero.v1.CheckSourcesRequest.timeout:type_name -> google.protobuf.Duration
+	11, // 10: subzero.v1.CheckSourcesResponse.checks:type_name -> subzero.v1.SourceCheck
+	3,  // 11: subzero.v1.SourceCheck.error:type_name -> subzero.v1.Error
+	12, // 12: subzero.v1.SourceCheck.duration:type_name -> google.protobuf.Duration
+	1,  // 13: subzero.v1.Subzero.Enumerate:input_type -> subzero.v1.EnumerateRequest
+	7,  // 14: subzero.v1.Subzero.ListSources:input_type -> subzero.v1.ListSourcesRequest
+	9,  // 15: subzero.v1.Subzero.CheckSources:input_type -> subzero.v1.CheckSourcesRequest
+	2,  // 16: subzero.v1.Subzero.Enumerate:output_type -> subzero.v1.Finding
+	8,  // 17: subzero.v1.Subzero.ListSources:output_type -> subzero.v1.ListSourcesResponse
+	10, // 18: subzero.v1.Subzero.CheckSources:output_type -> subzero.v1.CheckSourcesResponse
+	16, // [16:19] is the sub-list for method output_type
+	13, // [13:16] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_subzero_proto_init() }
+func file_subzero_proto_init() {
+	if File_subzero_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_subzero_proto_rawDesc), len(file_subzero_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_subzero_proto_goTypes,
+		DependencyIndexes: file_subzero_proto_depIdxs,
+		EnumInfos:         file_subzero_proto_enumTypes,
+		MessageInfos:      file_subzero_proto_msgTypes,
+	}.Build()
+	File_subzero_proto = out.File
+	file_subzero_proto_goTypes = nil
+	file_subzero_proto_depIdxs = nil
+}
@@ -0,0 +1,220 @@
+// The gRPC API of subzero, for services which want subdomain data without
+// shelling out to the CLI.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: subzero.proto
+
+package rpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Subzero_Enumerate_FullMethodName    = "/subzero.v1.Subzero/Enumerate"
+	Subzero_ListSources_FullMethodName  = "/subzero.v1.Subzero/ListSources"
+	Subzero_CheckSources_FullMethodName = "/subzero.v1.Subzero/CheckSources"
+)
+
+// SubzeroClient is the client API for Subzero service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Subzero enumerates subdomains.
+type SubzeroClient interface {
+	// Enumerate streams every finding for a domain as it's found. The stream
+	// ends once every source is done or the timeout is reached, in which case
+	// the "subzero-state" trailer is "timeout" instead of "done". Canceling the
+	// call cancels every source.
+	Enumerate(ctx context.Context, in *EnumerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error)
+	// ListSources lists the names of the available sources.
+	ListSources(ctx context.Context, in *ListSourcesRequest, opts ...grpc.CallOption) (*ListSourcesResponse, error)
+	// CheckSources queries sources for a domain, reporting which of them work.
+	CheckSources(ctx context.Context, in *CheckSourcesRequest, opts ...grpc.CallOption) (*CheckSourcesResponse, error)
+}
+
+type subzeroClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSubzeroClient(cc grpc.ClientConnInterface) SubzeroClient {
+	return &subzeroClient{cc}
+}
+
+func (c *subzeroClient) Enumerate(ctx context.Context, in *EnumerateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Finding], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Subzero_ServiceDesc.Streams[0], Subzero_Enumerate_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EnumerateRequest, Finding]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Subzero_EnumerateClient = grpc.ServerStreamingClient[Finding]
+
+func (c *subzeroClient) ListSources(ctx context.Context, in *ListSourcesRequest, opts ...grpc.CallOption) (*ListSourcesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSourcesResponse)
+	err := c.cc.Invoke(ctx, Subzero_ListSources_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *subzeroClient) CheckSources(ctx context.Context, in *CheckSourcesRequest, opts ...grpc.CallOption) (*CheckSourcesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckSourcesResponse)
+	err := c.cc.Invoke(ctx, Subzero_CheckSources_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SubzeroServer is the server API for Subzero service.
+// All implementations must embed UnimplementedSubzeroServer
+// for forward compatibility.
+//
+// Subzero enumerates subdomains.
+type SubzeroServer interface {
+	// Enumerate streams every finding for a domain as it's found. The stream
+	// ends once every source is done or the timeout is reached, in which case
+	// the "subzero-state" trailer is "timeout" instead of "done". Canceling the
+	// call cancels every source.
+	Enumerate(*EnumerateRequest, grpc.ServerStreamingServer[Finding]) error
+	// ListSources lists the names of the available sources.
+	ListSources(context.Context, *ListSourcesRequest) (*ListSourcesResponse, error)
+	// CheckSources queries sources for a domain, reporting which of them work.
+	CheckSources(context.Context, *CheckSourcesRequest) (*CheckSourcesResponse, error)
+	mustEmbedUnimplementedSubzeroServer()
+}
+
+// UnimplementedSubzeroServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSubzeroServer struct{}
+
+func (UnimplementedSubzeroServer) Enumerate(*EnumerateRequest, grpc.ServerStreamingServer[Finding]) error {
+	return status.Errorf(codes.Unimplemented, "method Enumerate not implemented")
+}
+func (UnimplementedSubzeroServer) ListSources(context.Context, *ListSourcesRequest) (*ListSourcesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSources not implemented")
+}
+func (UnimplementedSubzeroServer) CheckSources(context.Context, *CheckSourcesRequest) (*CheckSourcesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSources not implemented")
+}
+func (UnimplementedSubzeroServer) mustEmbedUnimplementedSubzeroServer() {}
+func (UnimplementedSubzeroServer) testEmbeddedByValue()                 {}
+
+// UnsafeSubzeroServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SubzeroServer will
+// result in compilation errors.
+type UnsafeSubzeroServer interface {
+	mustEmbedUnimplementedSubzeroServer()
+}
+
+func RegisterSubzeroServer(s grpc.ServiceRegistrar, srv SubzeroServer) {
+	// If the following call pancis, it indicates UnimplementedSubzeroServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Subzero_ServiceDesc, srv)
+}
+
+func _Subzero_Enumerate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EnumerateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SubzeroServer).Enumerate(m, &grpc.GenericServerStream[EnumerateRequest, Finding]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Subzero_EnumerateServer = grpc.ServerStreamingServer[Finding]
+
+func _Subzero_ListSources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSourcesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubzeroServer).ListSources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Subzero_ListSources_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubzeroServer).ListSources(ctx, req.(*ListSourcesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Subzero_CheckSources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckSourcesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SubzeroServer).CheckSources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Subzero_CheckSources_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SubzeroServer).CheckSources(ctx, req.(*CheckSourcesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Subzero_ServiceDesc is the grpc.ServiceDesc for Subzero service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Subzero_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "subzero.v1.Subzero",
+	HandlerType: (*SubzeroServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSources",
+			Handler:    _Subzero_ListSources_Handler,
+		},
+		{
+			MethodName: "CheckSources",
+			Handler:    _Subzero_CheckSources_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Enumerate",
+			Handler:       _Subzero_Enumerate_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "subzero.proto",
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/subfinder/research/core"
+)
+
+// The states a Job can be in.
+const (
+	JobQueued   = "queued"   // Waiting for one of the running jobs to finish.
+	JobRunning  = "running"  // Enumerating.
+	JobDone     = "done"     // Every source finished.
+	JobTimeout  = "timeout"  // Stopped by its timeout.
+	JobCanceled = "canceled" // Stopped by a client.
+)
+
+// JobRequest is the body used to start a Job.
+type JobRequest struct {
+	Domain         string   `json:"domain"`
+	Sources        []string `json:"sources,omitempty"`         // Names of the sources to use, defaults to all of them.
+	Recursive      bool     `json:"recursive,omitempty"`       // Use results to find more results.
+	Uniq           bool     `json:"uniq,omitempty"`            // Filter out duplicate results.
+	ExcludeExpired bool     `json:"exclude_expired,omitempty"` // Drop results only found on expired certificates.
+	Probe          bool     `json:"probe,omitempty"`           // Probe results over HTTP and HTTPS, to tell which are live.
+	Takeover       bool     `json:"takeover,omitempty"`        // Check results for subdomain takeovers.
+	Timeout        int64    `json:"timeout,omitempty"`         // Number of seconds until the job times out, defaults to the server's.
+}
+
+// JobStatus is the state of a Job, as returned to clients.
+type JobStatus struct {
+	ID       string     `json:"id"`
+	Domain   string     `json:"domain"`
+	Sources  []string   `json:"sources"`
+	Probe    bool       `json:"probe"`    // Whether results are probed, so those without probes aren't live.
+	Takeover bool       `json:"takeover"` // Whether results are checked for subdomain takeovers.
+	State    string     `json:"state"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Results  int        `json:"results"`  // Number of successful results so far.
+	Failures int        `json:"failures"` // Number of failed results so far.
+}
+
+// Job is a single enumeration started through the server.
+type Job struct {
+	sync.RWMutex
+	status  JobStatus
+	records []*core.OutputRecord
+	changed chan struct{} // Closed and replaced whenever anything changes.
+	cancel  context.CancelFunc
+	stopped bool // Whether the job was canceled by a client.
+}
+
+func newJob(id string, domain string, sources []string, request *JobRequest, cancel context.CancelFunc) *Job {
+	return &Job{
+		status: JobStatus{
+			ID:       id,
+			Domain:   domain,
+			Sources:  sources,
+			Probe:    request.Probe,
+			Takeover: request.Takeover,
+			State:    JobQueued,
+			Created:  time.Now().UTC(),
+		},
+		changed: make(chan struct{}),
+		cancel:  cancel,
+	}
+}
+
+// notify wakes up everyone waiting on the job, the lock must be held.
+func (job *Job) notify() {
+	close(job.changed)
+	job.changed = make(chan struct{})
+}
+
+// Status returns a copy of the job's status.
+func (job *Job) Status() JobStatus {
+	job.RLock()
+	defer job.RUnlock()
+	return job.status
+}
+
+// Finished checks if the job is done, one way or another.
+func (job *Job) Finished() bool {
+	job.RLock()
+	defer job.RUnlock()
+	return job.status.Finished != nil
+}
+
+// Records returns the records found from the given offset on, whether the job is
+// finished, and a channel which is closed once there is anything new.
+func (job *Job) Records(offset int) ([]*core.OutputRecord, bool, <-chan struct{}) {
+	job.RLock()
+	defer job.RUnlock()
+	if offset > len(job.records) {
+		offset = len(job.records)
+	}
+	return job.records[offset:len(job.records):len(job.records)], job.status.Finished != nil, job.changed
+}
+
+// Cancel stops the job if it's still queued or running.
+func (job *Job) Cancel() {
+	job.Lock()
+	if job.status.Finished == nil {
+		job.stopped = true
+	}
+	job.Unlock()
+	job.cancel()
+}
+
+func (job *Job) start() {
+	job.Lock()
+	defer job.Unlock()
+	now := time.Now().UTC()
+	job.status.Started = &now
+	job.status.State = JobRunning
+	job.notify()
+}
+
+func (job *Job) add(result *core.Result) {
+	record := core.NewOutputRecord(result)
+	job.Lock()
+	defer job.Unlock()
+	job.records = append(job.records, record)
+	if record.Error != "" {
+		job.status.Failures++
+	} else {
+		job.status.Results++
+	}
+	job.notify()
+}
+
+func (job *Job) finish(err error) {
+	job.Lock()
+	defer job.Unlock()
+	now := time.Now().UTC()
+	job.status.Finished = &now
+	switch {
+	case job.stopped:
+		job.status.State = JobCanceled
+	case err == context.DeadlineExceeded:
+		job.status.State = JobTimeout
+	default:
+		job.status.State = JobDone
+	}
+	job.notify()
+}
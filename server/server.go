@@ -0,0 +1,403 @@
+/*
+Package server exposes subdomain enumeration over a REST API, for tools
+which want subdomain data without shelling out to the CLI. Every job is
+a core.EnumerateSubdomains call running in the background, whose results
+can be polled or streamed live as Server-Sent Events or NDJSON.
+*/
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/subfinder/research/core"
+	"golang.org/x/sync/semaphore"
+)
+
+// Server is an http.Handler serving the REST API:
+//
+//	GET    /api/v1/sources            list the names of the available sources
+//	POST   /api/v1/jobs               start a job from a JobRequest, returning its JobStatus
+//	GET    /api/v1/jobs               list the JobStatus of every job
+//	GET    /api/v1/jobs/{id}          get the JobStatus of a job
+//	DELETE /api/v1/jobs/{id}          cancel a job
+//	GET    /api/v1/jobs/{id}/results  get {"status": JobStatus, "results": [core.OutputRecord, ...]}
+//	GET    /api/v1/jobs/{id}/stream   stream the results as they're found, see stream
+//
+// When any APIKeys are set, every request needs one of them in either an
+// "Authorization: Bearer <key>" or "X-API-Key: <key>" header.
+type Server struct {
+	Sources        []core.Source         // Sources which jobs can use.
+	APIKeys        []string              // Keys clients authenticate with, no authentication is done when empty.
+	MaxJobs        int                   // Number of jobs running at once, others are queued, defaults to 4.
+	DefaultTimeout time.Duration         // Timeout of jobs which don't set one, defaults to 5 minutes.
+	MaxTimeout     time.Duration         // Longest timeout a job can ask for, defaults to 1 hour.
+	Prober         *core.Prober          // Used by jobs asking for probes, defaults to an empty Prober.
+	Checker        *core.TakeoverChecker // Used by jobs asking for takeover checks, defaults to an empty TakeoverChecker.
+	Metrics        *core.Metrics         // Metrics to count the sources of jobs in, if any.
+	Logger         *slog.Logger          // Logger the sources of jobs log to, if any.
+	MaxFinished    int                   // Number of finished jobs kept, the oldest are removed, defaults to 100.
+	JobTTL         time.Duration         // How long finished jobs are kept, defaults to a day.
+	jobs           map[string]*Job
+	order          []string
+	mutex          sync.RWMutex
+	lock           *semaphore.Weighted
+	mux            *http.ServeMux
+	once           sync.Once
+}
+
+func (s *Server) init() {
+	s.once.Do(func() {
+		if s.MaxJobs <= 0 {
+			s.MaxJobs = 4
+		}
+		if s.DefaultTimeout <= 0 {
+			s.DefaultTimeout = 5 * time.Minute
+		}
+		if s.MaxTimeout <= 0 {
+			s.MaxTimeout = time.Hour
+		}
+		if s.Prober == nil {
+			s.Prober = &core.Prober{}
+		}
+		if s.Checker == nil {
+			s.Checker = &core.TakeoverChecker{}
+		}
+		if s.MaxFinished <= 0 {
+			s.MaxFinished = 100
+		}
+		if s.JobTTL <= 0 {
+			s.JobTTL = 24 * time.Hour
+		}
+		s.jobs = map[string]*Job{}
+		s.lock = semaphore.NewWeighted(int64(s.MaxJobs))
+		s.mux = http.NewServeMux()
+		s.mux.HandleFunc("/api/v1/sources", s.handleSources)
+		s.mux.HandleFunc("/api/v1/jobs", s.handleJobs)
+		s.mux.HandleFunc("/api/v1/jobs/", s.handleJob)
+	})
+}
+
+// Handle registers an extra handler behind the same authentication as the API.
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.init()
+	s.mux.Handle(pattern, handler)
+}
+
+// authorized checks the request's API key, if there are any.
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.APIKeys) == 0 {
+		return true
+	}
+
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key == "" {
+		return false
+	}
+
+	for _, allowed := range s.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(allowed)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP authenticates the request before handing it to the API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.init()
+
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="subzero"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid API key")
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// writeJSON writes the given object as the JSON response.
+func writeJSON(w http.ResponseWriter, status int, object interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(object)
+}
+
+// writeError writes a {"error": "..."} JSON response.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// SourceNames returns the names of the available sources, sorted.
+func (s *Server) SourceNames() []string {
+	names := []string{}
+	for _, source := range s.Sources {
+		names = append(names, core.SourceName(source))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newJobID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Start queues a new job for the given request.
+func (s *Server) Start(request *JobRequest) (*Job, error) {
+	s.init()
+
+	domain := core.NormalizeName(request.Domain)
+	if domain == "" || strings.ContainsAny(domain, " /:") {
+		return nil, fmt.Errorf("invalid domain %q", request.Domain)
+	}
+
+	sources, err := core.SelectSources(s.Sources, request.Sources)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, source := range sources {
+		names = append(names, core.SourceName(source))
+	}
+
+	timeout := s.DefaultTimeout
+	if request.Timeout > 0 {
+		timeout = time.Duration(request.Timeout) * time.Second
+	}
+	if timeout > s.MaxTimeout {
+		timeout = s.MaxTimeout
+	}
+
+	options := &core.EnumerationOptions{
+		Sources:        sources,
+		Recursive:      request.Recursive,
+		Uniq:           request.Uniq,
+		ExcludeExpired: request.ExcludeExpired,
+		Metrics:        s.Metrics,
+		Logger:         s.Logger,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := newJob(newJobID(), domain, names, request, cancel)
+
+	s.mutex.Lock()
+	s.prune()
+	s.jobs[job.status.ID] = job
+	s.order = append(s.order, job.status.ID)
+	s.mutex.Unlock()
+
+	go s.run(ctx, job, options, timeout)
+
+	return job, nil
+}
+
+// prune removes the finished jobs older than the JobTTL, and the oldest
+// beyond MaxFinished, along with their results. The mutex must be held.
+func (s *Server) prune() {
+	finished := 0
+	for _, id := range s.order {
+		if s.jobs[id].Finished() {
+			finished++
+		}
+	}
+
+	expired := time.Now().Add(-s.JobTTL)
+	order := s.order[:0]
+	for _, id := range s.order {
+		status := s.jobs[id].Status()
+		if status.Finished != nil && (finished > s.MaxFinished || status.Finished.Before(expired)) {
+			finished--
+			delete(s.jobs, id)
+			continue
+		}
+		order = append(order, id)
+	}
+	s.order = order
+}
+
+// run waits for a free slot and enumerates the job's domain.
+func (s *Server) run(cancelCtx context.Context, job *Job, options *core.EnumerationOptions, timeout time.Duration) {
+	if err := s.lock.Acquire(cancelCtx, 1); err != nil {
+		job.finish(err)
+		return
+	}
+	defer s.lock.Release(1)
+
+	job.start()
+
+	ctx, cancel := context.WithTimeout(cancelCtx, timeout)
+	defer cancel()
+
+	status := job.Status()
+
+	output := core.EnumerateSubdomains(ctx, status.Domain, options)
+	// checks finish what was found even after a timeout, but not a cancellation
+	if status.Takeover {
+		output = s.Checker.CheckResults(cancelCtx, output)
+	}
+	if status.Probe {
+		output = s.Prober.ProbeResults(cancelCtx, output)
+	}
+
+	for result := range output {
+		job.add(result)
+	}
+
+	job.finish(ctx.Err())
+}
+
+// Job returns the job with the given ID, or nil.
+func (s *Server) Job(id string) *Job {
+	s.init()
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.jobs[id]
+}
+
+func (s *Server) handleSources(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"sources": s.SourceNames()})
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.mutex.RLock()
+		statuses := []JobStatus{}
+		for _, id := range s.order {
+			statuses = append(statuses, s.jobs[id].Status())
+		}
+		s.mutex.RUnlock()
+		writeJSON(w, http.StatusOK, map[string][]JobStatus{"jobs": statuses})
+	case http.MethodPost:
+		request := &JobRequest{}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(request); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid job request: "+err.Error())
+			return
+		}
+		job, err := s.Start(request)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		w.Header().Set("Location", "/api/v1/jobs/"+job.status.ID)
+		writeJSON(w, http.StatusAccepted, job.Status())
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/"), "/")
+	if parts[0] == "" {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	job := s.Job(parts[0])
+	if job == nil || len(parts) > 2 {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, job.Status())
+	case action == "" && r.Method == http.MethodDelete:
+		job.Cancel()
+		writeJSON(w, http.StatusOK, job.Status())
+	case action == "results" && r.Method == http.MethodGet:
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		records, _, _ := job.Records(offset)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": job.Status(), "results": records})
+	case action == "stream" && r.Method == http.MethodGet:
+		s.stream(w, r, job)
+	case action == "" || action == "results" || action == "stream":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// stream writes every result of the job as it's found, until the job is finished or the
+// client goes away. Clients asking for text/event-stream get Server-Sent Events, with a
+// "result" event for every result and a final "status" event. Everyone else gets NDJSON,
+// one result object per line. The offset query parameter skips results already seen.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, job *Job) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+
+	sse := strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if lastID, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && sse {
+		offset = lastID + 1
+	}
+
+	encoder := json.NewEncoder(w)
+
+	for {
+		records, finished, changed := job.Records(offset)
+
+		for _, record := range records {
+			if sse {
+				data, _ := json.Marshal(record)
+				fmt.Fprintf(w, "id: %d\nevent: result\ndata: %s\n\n", offset, data)
+			} else {
+				encoder.Encode(record)
+			}
+			offset++
+		}
+		flusher.Flush()
+
+		if finished {
+			if sse {
+				data, _ := json.Marshal(job.Status())
+				fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+				flusher.Flush()
+			}
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/subfinder/research/core"
+)
+
+// FakeSource finds a few names right away.
+type FakeSource struct{}
+
+func (s *FakeSource) ProcessDomain(ctx context.Context, domain string) <-chan *core.Result {
+	results := make(chan *core.Result)
+	go func() {
+		defer close(results)
+		for _, name := range []string{"a.", "b.", "c."} {
+			select {
+			case results <- core.NewResult("fake", name+domain, nil):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// SlowSource finds nothing until its context is canceled.
+type SlowSource struct{}
+
+func (s *SlowSource) ProcessDomain(ctx context.Context, domain string) <-chan *core.Result {
+	results := make(chan *core.Result)
+	go func() {
+		defer close(results)
+		<-ctx.Done()
+	}()
+	return results
+}
+
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	s := &Server{
+		Sources: []core.Source{&FakeSource{}, &SlowSource{}},
+		APIKeys: []string{"secret"},
+		MaxJobs: 1,
+	}
+	server := httptest.NewServer(s)
+	t.Cleanup(server.Close)
+	return s, server
+}
+
+func request(t *testing.T, method, url string, body interface{}) *http.Response {
+	var buffer bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buffer).Encode(body)
+	}
+	req, err := http.NewRequest(method, url, &buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response, object interface{}) {
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(object); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func startJob(t *testing.T, server *httptest.Server, jobRequest *JobRequest) JobStatus {
+	resp := request(t, http.MethodPost, server.URL+"/api/v1/jobs", jobRequest)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("expected '202 Accepted', got '%v'", resp.Status)
+	}
+	status := JobStatus{}
+	decode(t, resp, &status)
+	return status
+}
+
+func waitForJob(t *testing.T, s *Server, id string) JobStatus {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if job := s.Job(id); job != nil && job.Finished() {
+			return job.Status()
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %v didn't finish", id)
+	return JobStatus{}
+}
+
+func TestServer_Authentication(t *testing.T) {
+	_, server := newTestServer(t)
+
+	var units = []struct {
+		header string
+		value  string
+		exp    int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"Authorization", "Bearer wrong", http.StatusUnauthorized},
+		{"Authorization", "Bearer secret", http.StatusOK},
+		{"X-API-Key", "secret", http.StatusOK},
+	}
+	for _, u := range units {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/sources", nil)
+		if u.header != "" {
+			req.Header.Set(u.header, u.value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != u.exp {
+			t.Fatalf("expected %d for '%v: %v', got %d", u.exp, u.header, u.value, resp.StatusCode)
+		}
+	}
+}
+
+func TestServer_Jobs(t *testing.T) {
+	s, server := newTestServer(t)
+
+	sources := map[string][]string{}
+	decode(t, request(t, http.MethodGet, server.URL+"/api/v1/sources", nil), &sources)
+	if strings.Join(sources["sources"], ",") != "FakeSource,SlowSource" {
+		t.Fatalf("expected 'FakeSource,SlowSource', got '%v'", sources["sources"])
+	}
+
+	resp := request(t, http.MethodPost, server.URL+"/api/v1/jobs", &JobRequest{Domain: "example.com", Sources: []string{"nope"}})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected '400 Bad Request' for an unknown source, got '%v'", resp.Status)
+	}
+
+	status := startJob(t, server, &JobRequest{Domain: "Example.com.", Sources: []string{"fakesource"}})
+	if status.Domain != "example.com" || strings.Join(status.Sources, ",") != "FakeSource" {
+		t.Fatalf("expected a FakeSource job for example.com, got '%+v'", status)
+	}
+
+	status = waitForJob(t, s, status.ID)
+	if status.State != JobDone || status.Results != 3 {
+		t.Fatalf("expected a done job with 3 results, got '%+v'", status)
+	}
+
+	results := struct {
+		Status  JobStatus
+		Results []*core.OutputRecord
+	}{}
+	decode(t, request(t, http.MethodGet, server.URL+"/api/v1/jobs/"+status.ID+"/results", nil), &results)
+	if len(results.Results) != 3 || results.Results[0].Domain != "example.com" || results.Results[0].Source != "fake" {
+		t.Fatalf("expected 3 results, got '%+v'", results.Results)
+	}
+
+	resp = request(t, http.MethodGet, server.URL+"/api/v1/jobs/nope", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected '404 Not Found', got '%v'", resp.Status)
+	}
+}
+
+func TestServer_Prune(t *testing.T) {
+	s, server := newTestServer(t)
+	s.MaxFinished = 1
+
+	first := waitForJob(t, s, startJob(t, server, &JobRequest{Domain: "example.com", Sources: []string{"FakeSource"}}).ID)
+	second := waitForJob(t, s, startJob(t, server, &JobRequest{Domain: "example.org", Sources: []string{"FakeSource"}}).ID)
+	third := startJob(t, server, &JobRequest{Domain: "example.net", Sources: []string{"FakeSource"}})
+	waitForJob(t, s, third.ID)
+
+	// starting the third job removed the first, as only one finished job is kept
+	if s.Job(first.ID) != nil || s.Job(second.ID) == nil || s.Job(third.ID) == nil {
+		t.Fatal("expected only the oldest finished job to be removed")
+	}
+	resp := request(t, http.MethodGet, server.URL+"/api/v1/jobs/"+first.ID+"/results", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected '404 Not Found' for a removed job, got '%v'", resp.Status)
+	}
+
+	// expired jobs are removed regardless
+	s.JobTTL = time.Nanosecond
+	fourth := startJob(t, server, &JobRequest{Domain: "example.com", Sources: []string{"FakeSource"}})
+	if s.Job(second.ID) != nil || s.Job(third.ID) != nil || s.Job(fourth.ID) == nil {
+		t.Fatal("expected the expired jobs to be removed")
+	}
+
+	resp = request(t, http.MethodGet, server.URL+"/api/v1/jobs/", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected '404 Not Found' for an empty job ID, got '%v'", resp.Status)
+	}
+}
+
+func TestServer_Metrics(t *testing.T) {
+	s, server := newTestServer(t)
+	s.Metrics = core.NewMetrics()
+	s.Handle("/metrics", s.Metrics)
+
+	status := waitForJob(t, s, startJob(t, server, &JobRequest{Domain: "example.com", Sources: []string{"FakeSource"}}).ID)
+	if status.Results != 3 {
+		t.Fatalf("expected 3 results, got '%+v'", status)
+	}
+
+	resp := request(t, http.MethodGet, server.URL+"/metrics", nil)
+	defer resp.Body.Close()
+	body := &bytes.Buffer{}
+	body.ReadFrom(resp.Body)
+	if !strings.Contains(body.String(), `subzero_source_results_total{source="FakeSource"} 3`) || !strings.Contains(body.String(), `subzero_findings_total{domain="example.com"} 3`) {
+		t.Fatalf("expected the results of the job in the metrics, got:\n%v", body)
+	}
+}
+
+func TestServer_CancelAndQueue(t *testing.T) {
+	s, server := newTestServer(t)
+
+	slow := startJob(t, server, &JobRequest{Domain: "example.com", Sources: []string{"SlowSource"}})
+	queued := startJob(t, server, &JobRequest{Domain: "example.org", Sources: []string{"FakeSource"}})
+
+	// only one job runs at a time
+	time.Sleep(50 * time.Millisecond)
+	if state := s.Job(queued.ID).Status().State; state != JobQueued {
+		t.Fatalf("expected the second job to be queued, got '%v'", state)
+	}
+
+	resp := request(t, http.MethodDelete, server.URL+"/api/v1/jobs/"+slow.ID, nil)
+	resp.Body.Close()
+
+	if status := waitForJob(t, s, slow.ID); status.State != JobCanceled {
+		t.Fatalf("expected the first job to be canceled, got '%v'", status.State)
+	}
+	if status := waitForJob(t, s, queued.ID); status.State != JobDone {
+		t.Fatalf("expected the second job to be done, got '%v'", status.State)
+	}
+
+	timedOut := startJob(t, server, &JobRequest{Domain: "example.com", Sources: []string{"SlowSource"}, Timeout: 1})
+	if status := waitForJob(t, s, timedOut.ID); status.State != JobTimeout {
+		t.Fatalf("expected the job to time out, got '%v'", status.State)
+	}
+}
+
+func TestServer_Stream(t *testing.T) {
+	_, server := newTestServer(t)
+
+	status := startJob(t, server, &JobRequest{Domain: "example.com", Sources: []string{"FakeSource"}})
+
+	resp := request(t, http.MethodGet, server.URL+"/api/v1/jobs/"+status.ID+"/stream", nil)
+	if resp.Header.Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("expected NDJSON, got '%v'", resp.Header.Get("Content-Type"))
+	}
+	lines := 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		record := &core.OutputRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			t.Fatal(err)
+		}
+		lines++
+	}
+	resp.Body.Close()
+	if lines != 3 {
+		t.Fatalf("expected 3 streamed results, got %d", lines)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/api/v1/jobs/"+status.ID+"/stream", nil)
+	req.Header.Set("X-API-Key", "secret")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := []string{}
+	scanner = bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "event: ") {
+			events = append(events, strings.TrimPrefix(scanner.Text(), "event: "))
+		}
+	}
+	resp.Body.Close()
+	if strings.Join(events, ",") != "result,result,status" {
+		t.Fatalf("expected the results after the last event and a status, got '%v'", events)
+	}
+}
@@ -0,0 +1,438 @@
+// subzero dashboard: a thin client of the REST API served next to it.
+(function () {
+  "use strict";
+
+  var $ = function (id) { return document.getElementById(id); };
+
+  var state = {
+    jobs: [],        // JobStatus of every job, oldest first.
+    job: null,       // JobStatus of the selected job.
+    records: [],     // OutputRecords of the selected job.
+    compare: null,   // OutputRecords of the job compared against, if any.
+    stream: null     // AbortController of the selected job's stream.
+  };
+
+  // API
+
+  function apiKey() {
+    return localStorage.getItem("subzero-api-key") || "";
+  }
+
+  function api(method, path, body) {
+    var headers = { "X-API-Key": apiKey() };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    return fetch(path, {
+      method: method,
+      headers: headers,
+      body: body === undefined ? undefined : JSON.stringify(body)
+    }).then(function (resp) {
+      return resp.json().then(function (data) {
+        if (!resp.ok) {
+          throw new Error(data.error || resp.statusText);
+        }
+        return data;
+      });
+    });
+  }
+
+  // stream reads the NDJSON results of a job as they're found, EventSource
+  // can't be used since it doesn't send the API key.
+  function stream(job, offset, onRecord, onDone) {
+    var controller = new AbortController();
+    fetch("/api/v1/jobs/" + job.id + "/stream?offset=" + offset, {
+      headers: { "X-API-Key": apiKey(), "Accept": "application/x-ndjson" },
+      signal: controller.signal
+    }).then(function (resp) {
+      if (!resp.ok) {
+        throw new Error(resp.statusText);
+      }
+      var reader = resp.body.getReader();
+      var decoder = new TextDecoder();
+      var buffer = "";
+      function read() {
+        return reader.read().then(function (chunk) {
+          if (chunk.done) {
+            onDone();
+            return;
+          }
+          buffer += decoder.decode(chunk.value, { stream: true });
+          var lines = buffer.split("\n");
+          buffer = lines.pop();
+          lines.forEach(function (line) {
+            if (line.trim() !== "") {
+              onRecord(JSON.parse(line));
+            }
+          });
+          return read();
+        });
+      }
+      return read();
+    }).catch(function (err) {
+      if (err.name !== "AbortError") {
+        showError(err);
+      }
+    });
+    return controller;
+  }
+
+  function showError(err) {
+    $("error").textContent = err ? err.message : "";
+    $("error").hidden = !err;
+  }
+
+  // Records
+
+  function liveness(record, job) {
+    if (record.probes && record.probes.length > 0) {
+      return "live";
+    }
+    return job && job.probe ? "dead" : "unknown";
+  }
+
+  function tags(record) {
+    var found = [];
+    if (record.related) {
+      found.push("related");
+    }
+    if (record.takeover && record.takeover.vulnerable) {
+      found.push("takeover");
+    }
+    if (record.certificate && record.certificate.expired) {
+      found.push("expired");
+    }
+    if (record.certificate && record.certificate.precertificate) {
+      found.push("precertificate");
+    }
+    if (record.error) {
+      found.push("error");
+    }
+    return found;
+  }
+
+  function names(records) {
+    var set = {};
+    records.forEach(function (record) {
+      if (record.subdomain) {
+        set[record.subdomain] = true;
+      }
+    });
+    return set;
+  }
+
+  // filtered returns the selected job's records matching the filters, with
+  // the names only found by the compared job added as removed rows.
+  function filtered() {
+    var text = $("filter-text").value.toLowerCase();
+    var source = $("filter-source").value;
+    var live = $("filter-liveness").value;
+    var tag = $("filter-tag").value;
+
+    var rows = state.records.map(function (record) {
+      return { record: record, change: "" };
+    });
+
+    if (state.compare) {
+      var before = names(state.compare);
+      var after = names(state.records);
+      rows.forEach(function (row) {
+        if (row.record.subdomain && !before[row.record.subdomain]) {
+          row.change = "added";
+        }
+      });
+      var removed = {};
+      state.compare.forEach(function (record) {
+        if (record.subdomain && !after[record.subdomain] && !removed[record.subdomain]) {
+          removed[record.subdomain] = true;
+          rows.push({ record: record, change: "removed" });
+        }
+      });
+    }
+
+    return rows.filter(function (row) {
+      var record = row.record;
+      if (text && (record.subdomain || record.error || "").toLowerCase().indexOf(text) < 0) {
+        return false;
+      }
+      if (source && record.source !== source) {
+        return false;
+      }
+      if (live && liveness(record, state.job) !== live) {
+        return false;
+      }
+      if (tag && tags(record).indexOf(tag) < 0) {
+        return false;
+      }
+      return true;
+    });
+  }
+
+  // Rendering
+
+  function cell(row, content) {
+    var td = document.createElement("td");
+    if (content instanceof Node) {
+      td.appendChild(content);
+    } else {
+      td.textContent = content;
+    }
+    row.appendChild(td);
+  }
+
+  function renderResults() {
+    var rows = filtered();
+    var body = $("results");
+    body.textContent = "";
+
+    rows.forEach(function (row) {
+      var record = row.record;
+      var tr = document.createElement("tr");
+      tr.className = row.change;
+
+      cell(tr, record.subdomain || record.error);
+      cell(tr, record.source);
+      cell(tr, liveness(record, state.job));
+
+      var tagList = document.createElement("span");
+      tags(record).forEach(function (tag) {
+        var span = document.createElement("span");
+        span.className = "tag " + tag;
+        span.textContent = tag;
+        tagList.appendChild(span);
+      });
+      cell(tr, tagList);
+      cell(tr, new Date(record.timestamp).toLocaleTimeString());
+
+      body.appendChild(tr);
+    });
+
+    var summary = rows.length + " of " + state.records.length + " results";
+    if (state.compare) {
+      var added = rows.filter(function (row) { return row.change === "added"; }).length;
+      var removed = rows.filter(function (row) { return row.change === "removed"; }).length;
+      summary += ", " + added + " added, " + removed + " removed";
+    }
+    $("summary").textContent = state.job ? summary : "";
+  }
+
+  function renderSources() {
+    var select = $("filter-source");
+    var selected = select.value;
+    var seen = {};
+    state.records.forEach(function (record) { seen[record.source] = true; });
+
+    select.length = 1;
+    Object.keys(seen).sort().forEach(function (source) {
+      select.add(new Option(source, source, false, source === selected));
+    });
+  }
+
+  function renderJob() {
+    var job = state.job;
+    $("job-title").textContent = job ? job.domain : "Select or start a run";
+    $("job-state").textContent = job ? job.state + ", " + job.results + " results, " + job.failures + " errors" : "";
+    $("cancel-job").hidden = !job || job.state === "done" || job.state === "timeout" || job.state === "canceled";
+  }
+
+  function renderJobs() {
+    var list = $("jobs");
+    list.textContent = "";
+
+    var compare = $("compare");
+    var compared = compare.value;
+    compare.length = 1;
+
+    state.jobs.slice().reverse().forEach(function (job) {
+      var li = document.createElement("li");
+      li.textContent = job.domain;
+      if (state.job && state.job.id === job.id) {
+        li.className = "selected";
+      }
+      var small = document.createElement("small");
+      small.textContent = job.state + " · " + job.results + " results · " + new Date(job.created).toLocaleString();
+      li.appendChild(small);
+      li.addEventListener("click", function () { select(job); });
+      list.appendChild(li);
+
+      if (!state.job || state.job.id !== job.id) {
+        var label = job.domain + " (" + new Date(job.created).toLocaleString() + ")";
+        compare.add(new Option(label, job.id, false, job.id === compared));
+      }
+    });
+  }
+
+  // Actions
+
+  function refreshJobs() {
+    return api("GET", "/api/v1/jobs").then(function (data) {
+      state.jobs = data.jobs;
+      state.jobs.forEach(function (job) {
+        if (state.job && state.job.id === job.id) {
+          state.job = job;
+        }
+      });
+      renderJobs();
+      renderJob();
+      showError(null);
+    }).catch(showError);
+  }
+
+  function loadSources() {
+    return api("GET", "/api/v1/sources").then(function (data) {
+      var fieldset = $("sources");
+      fieldset.querySelectorAll("label").forEach(function (label) { label.remove(); });
+      data.sources.forEach(function (source) {
+        var label = document.createElement("label");
+        var input = document.createElement("input");
+        input.type = "checkbox";
+        input.value = source;
+        input.checked = true;
+        label.appendChild(input);
+        label.appendChild(document.createTextNode(" " + source));
+        fieldset.appendChild(label);
+      });
+    }).catch(showError);
+  }
+
+  function select(job) {
+    if (state.stream) {
+      state.stream.abort();
+    }
+    state.job = job;
+    state.records = [];
+    state.compare = null;
+    $("compare").value = "";
+    renderJobs();
+    renderJob();
+    renderResults();
+
+    state.stream = stream(job, 0, function (record) {
+      state.records.push(record);
+      renderSources();
+      renderResults();
+    }, function () {
+      refreshJobs();
+    });
+  }
+
+  function start(event) {
+    event.preventDefault();
+
+    var sources = [];
+    var boxes = $("sources").querySelectorAll("input[type=checkbox]");
+    boxes.forEach(function (input) {
+      if (input.checked) {
+        sources.push(input.value);
+      }
+    });
+
+    var request = {
+      domain: $("domain").value.trim(),
+      uniq: $("opt-uniq").checked,
+      recursive: $("opt-recursive").checked,
+      probe: $("opt-probe").checked,
+      takeover: $("opt-takeover").checked,
+      exclude_expired: $("opt-exclude-expired").checked
+    };
+    if (sources.length < boxes.length) {
+      request.sources = sources;
+    }
+    var timeout = parseInt($("opt-timeout").value, 10);
+    if (timeout > 0) {
+      request.timeout = timeout;
+    }
+
+    api("POST", "/api/v1/jobs", request).then(function (job) {
+      state.jobs.push(job);
+      select(job);
+      showError(null);
+    }).catch(showError);
+  }
+
+  function compareWith() {
+    var id = $("compare").value;
+    if (!id) {
+      state.compare = null;
+      renderResults();
+      return;
+    }
+    api("GET", "/api/v1/jobs/" + id + "/results").then(function (data) {
+      state.compare = data.results || [];
+      renderResults();
+    }).catch(showError);
+  }
+
+  function csvField(value) {
+    value = String(value === undefined || value === null ? "" : value);
+    return /[",\n]/.test(value) ? '"' + value.replace(/"/g, '""') + '"' : value;
+  }
+
+  // exportResults downloads the rows shown, in the same formats as the CLI's --output-format.
+  function exportResults() {
+    var records = filtered().filter(function (row) {
+      return row.change !== "removed";
+    }).map(function (row) {
+      return row.record;
+    });
+
+    var format = $("export-format").value;
+    var content;
+    var type;
+    if (format === "jsonl") {
+      content = records.map(function (record) { return JSON.stringify(record); }).join("\n");
+      type = "application/x-ndjson";
+    } else if (format === "csv") {
+      var lines = [["timestamp", "domain", "source", "subdomain", "related", "error", "takeover", "probes"].join(",")];
+      records.forEach(function (record) {
+        var takeover = "";
+        if (record.takeover) {
+          takeover = (record.takeover.vulnerable ? "vulnerable to " : "possible ") +
+            record.takeover.service + " takeover: " + record.takeover.reason;
+        }
+        var probes = (record.probes || []).map(function (probe) { return probe.final_url; }).join(" ");
+        lines.push([
+          record.timestamp, record.domain, record.source, record.subdomain,
+          record.related ? "true" : "false", record.error, takeover, probes
+        ].map(csvField).join(","));
+      });
+      content = lines.join("\n");
+      type = "text/csv";
+    } else {
+      content = records.filter(function (record) {
+        return record.subdomain;
+      }).map(function (record) {
+        return record.subdomain;
+      }).join("\n");
+      type = "text/plain";
+    }
+
+    var link = document.createElement("a");
+    link.href = URL.createObjectURL(new Blob([content + "\n"], { type: type }));
+    link.download = (state.job ? state.job.domain : "results") + "." + format;
+    link.click();
+    URL.revokeObjectURL(link.href);
+  }
+
+  // Setup
+
+  $("api-key").value = apiKey();
+  $("api-key").addEventListener("change", function () {
+    localStorage.setItem("subzero-api-key", $("api-key").value);
+    loadSources();
+    refreshJobs();
+  });
+  $("new-job").addEventListener("submit", start);
+  $("cancel-job").addEventListener("click", function () {
+    api("DELETE", "/api/v1/jobs/" + state.job.id).then(refreshJobs).catch(showError);
+  });
+  ["filter-text", "filter-source", "filter-liveness", "filter-tag"].forEach(function (id) {
+    $(id).addEventListener("input", renderResults);
+  });
+  $("compare").addEventListener("change", compareWith);
+  $("export").addEventListener("click", exportResults);
+
+  loadSources();
+  refreshJobs();
+  setInterval(refreshJobs, 5000);
+})();
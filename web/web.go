@@ -0,0 +1,26 @@
+/*
+Package web is a small dashboard for the REST API of package server, embedded
+in the binary. It lets people who don't use the CLI start enumerations, watch
+the results arrive, filter them, compare runs and export them.
+*/
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard. It only contains static files, which talk to
+// the REST API under /api/v1/ with the API key entered in the dashboard, so it
+// can be served without authentication next to it.
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(files))
+}
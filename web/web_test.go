@@ -0,0 +1,41 @@
+package web
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	var units = []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{"/", "text/html", "<script src=\"app.js\"></script>"},
+		{"/app.js", "javascript", "/api/v1/jobs"},
+		{"/style.css", "text/css", "body"},
+	}
+	for _, u := range units {
+		resp, err := http.Get(server.URL + u.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%v: expected '200 OK', got '%v'", u.path, resp.Status)
+		}
+		if !strings.Contains(resp.Header.Get("Content-Type"), u.contentType) {
+			t.Fatalf("%v: expected '%v', got '%v'", u.path, u.contentType, resp.Header.Get("Content-Type"))
+		}
+		if !strings.Contains(string(body), u.contains) {
+			t.Fatalf("%v: expected it to contain '%v'", u.path, u.contains)
+		}
+	}
+}